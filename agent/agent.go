@@ -1,10 +1,12 @@
 package agent
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 
@@ -15,15 +17,51 @@ import (
 	"github.com/influxdata/telegraf/selfstat"
 )
 
+// ErrRestartRequired is returned by Reload when the new configuration can
+// only be applied by restarting the agent.
+var ErrRestartRequired = errors.New("agent or global_tags configuration changed")
+
 // Agent runs telegraf and collects data based on the given config
 type Agent struct {
 	Config *config.Config
+
+	// mu guards the plugin slices of Config, which are replaced when the
+	// configuration is reloaded.
+	mu sync.RWMutex
+
+	// flushMu serializes writing the outputs, so that outputs removed by a
+	// reload are not closed while they are written.
+	flushMu sync.Mutex
+
+	metricC chan telegraf.Metric
+	aggC    chan telegraf.Metric
+
+	// taskMu guards the goroutines running each input and aggregator.
+	taskMu      sync.Mutex
+	inputs      map[*models.RunningInput]*task
+	aggregators map[*models.RunningAggregator]*task
+}
+
+// task is a goroutine running a single plugin, which can be stopped without
+// stopping the rest of the agent.
+type task struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newTask() *task {
+	return &task{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
 }
 
 // NewAgent returns an Agent struct based off the given Config
 func NewAgent(config *config.Config) (*Agent, error) {
 	a := &Agent{
-		Config: config,
+		Config:      config,
+		inputs:      make(map[*models.RunningInput]*task),
+		aggregators: make(map[*models.RunningAggregator]*task),
 	}
 
 	if !a.Config.Agent.OmitHostname {
@@ -45,40 +83,52 @@ func NewAgent(config *config.Config) (*Agent, error) {
 // Connect connects to all configured outputs
 func (a *Agent) Connect() error {
 	for _, o := range a.Config.Outputs {
-		switch ot := o.Output.(type) {
-		case telegraf.ServiceOutput:
-			if err := ot.Start(); err != nil {
-				log.Printf("E! Service for output %s failed to start, exiting\n%s\n",
-					o.Name, err.Error())
-				return err
-			}
+		if err := a.connectOutput(o); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		log.Printf("D! Attempting connection to output: %s\n", o.Name)
-		err := o.Output.Connect()
-		if err != nil {
-			log.Printf("E! Failed to connect to output %s, retrying in 15s, "+
-				"error was '%s' \n", o.Name, err)
-			time.Sleep(15 * time.Second)
-			err = o.Output.Connect()
-			if err != nil {
-				return err
-			}
+// connectOutput starts and connects a single output.
+func (a *Agent) connectOutput(o *models.RunningOutput) error {
+	if o.Config.BufferDirectory != "" {
+		if err := o.OpenDiskBuffer(); err != nil {
+			log.Printf("E! Could not open buffer for output %s\n", o.Name)
+			return err
 		}
-		log.Printf("D! Successfully connected to output: %s\n", o.Name)
 	}
-	return nil
+
+	switch ot := o.Output.(type) {
+	case telegraf.ServiceOutput:
+		if err := ot.Start(); err != nil {
+			log.Printf("E! Service for output %s failed to start, exiting\n%s\n",
+				o.Name, err.Error())
+			// release the disk buffer opened above
+			o.Close()
+			return err
+		}
+	}
+
+	// An output that cannot be connected does not prevent the agent from
+	// starting, it is reconnected in the background.
+	return o.Connect()
 }
 
 // Close closes the connection to all configured outputs
 func (a *Agent) Close() error {
 	var err error
 	for _, o := range a.Config.Outputs {
-		err = o.Output.Close()
-		switch ot := o.Output.(type) {
-		case telegraf.ServiceOutput:
-			ot.Stop()
-		}
+		err = closeOutput(o)
+	}
+	return err
+}
+
+func closeOutput(o *models.RunningOutput) error {
+	err := o.Close()
+	switch ot := o.Output.(type) {
+	case telegraf.ServiceOutput:
+		ot.Stop()
 	}
 	return err
 }
@@ -221,12 +271,27 @@ func (a *Agent) Test() error {
 	return nil
 }
 
+// plugins returns the outputs, processors and aggregators currently in use.
+func (a *Agent) plugins() (
+	[]*models.RunningOutput,
+	models.RunningProcessors,
+	[]*models.RunningAggregator,
+) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.Config.Outputs, a.Config.Processors, a.Config.Aggregators
+}
+
 // flush writes a list of metrics to all configured outputs
 func (a *Agent) flush() {
+	a.flushMu.Lock()
+	defer a.flushMu.Unlock()
+
 	var wg sync.WaitGroup
 
-	wg.Add(len(a.Config.Outputs))
-	for _, o := range a.Config.Outputs {
+	outputs, _, _ := a.plugins()
+	wg.Add(len(outputs))
+	for _, o := range outputs {
 		go func(output *models.RunningOutput) {
 			defer wg.Done()
 			err := output.Write()
@@ -262,17 +327,18 @@ func (a *Agent) flusher(shutdown chan struct{}, metricC chan telegraf.Metric, ag
 				}
 				return
 			case m := <-outMetricC:
+				outputs, _, aggregators := a.plugins()
 				// if dropOriginal is set to true, then we will only send this
 				// metric to the aggregators, not the outputs.
 				var dropOriginal bool
-				for _, agg := range a.Config.Aggregators {
+				for _, agg := range aggregators {
 					if ok := agg.Add(m.Copy()); ok {
 						dropOriginal = true
 					}
 				}
 				if !dropOriginal {
-					for i, o := range a.Config.Outputs {
-						if i == len(a.Config.Outputs)-1 {
+					for i, o := range outputs {
+						if i == len(outputs)-1 {
 							o.AddMetric(m)
 						} else {
 							o.AddMetric(m.Copy())
@@ -295,13 +361,14 @@ func (a *Agent) flusher(shutdown chan struct{}, metricC chan telegraf.Metric, ag
 				}
 				return
 			case metric := <-aggC:
+				outputs, processors, _ := a.plugins()
 				metrics := []telegraf.Metric{metric}
-				for _, processor := range a.Config.Processors {
+				for _, processor := range processors {
 					metrics = processor.Apply(metrics...)
 				}
 				for _, m := range metrics {
-					for i, o := range a.Config.Outputs {
-						if i == len(a.Config.Outputs)-1 {
+					for i, o := range outputs {
+						if i == len(outputs)-1 {
 							o.AddMetric(m)
 						} else {
 							o.AddMetric(m.Copy())
@@ -338,8 +405,9 @@ func (a *Agent) flusher(shutdown chan struct{}, metricC chan telegraf.Metric, ag
 		case metric := <-metricC:
 			// NOTE potential bottleneck here as we put each metric through the
 			// processors serially.
+			_, processors, _ := a.plugins()
 			mS := []telegraf.Metric{metric}
-			for _, processor := range a.Config.Processors {
+			for _, processor := range processors {
 				mS = processor.Apply(mS...)
 			}
 			for _, m := range mS {
@@ -359,24 +427,24 @@ func (a *Agent) Run(shutdown chan struct{}) error {
 		a.Config.Agent.Hostname, a.Config.Agent.FlushInterval.Duration)
 
 	// channel shared between all input threads for accumulating metrics
-	metricC := make(chan telegraf.Metric, 100)
-	aggC := make(chan telegraf.Metric, 100)
+	a.metricC = make(chan telegraf.Metric, 100)
+	a.aggC = make(chan telegraf.Metric, 100)
+
+	a.taskMu.Lock()
+	defer a.taskMu.Unlock()
 
 	// Start all ServicePlugins
 	for _, input := range a.Config.Inputs {
-		input.SetDefaultTags(a.Config.Tags)
-		switch p := input.Input.(type) {
-		case telegraf.ServiceInput:
-			acc := NewAccumulator(input, metricC)
-			// Service input plugins should set their own precision of their
-			// metrics.
-			acc.SetPrecision(time.Nanosecond, 0)
-			if err := p.Start(acc); err != nil {
-				log.Printf("E! Service for input %s failed to start, exiting\n%s\n",
-					input.Name(), err.Error())
-				return err
+		a.inputs[input] = newTask()
+		if err := a.startService(input); err != nil {
+			log.Printf("E! Service for input %s failed to start, exiting\n%s\n",
+				input.Name(), err.Error())
+			delete(a.inputs, input)
+			for input := range a.inputs {
+				stopService(input)
+				delete(a.inputs, input)
 			}
-			defer p.Stop()
+			return err
 		}
 	}
 
@@ -389,37 +457,338 @@ func (a *Agent) Run(shutdown chan struct{}) error {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if err := a.flusher(shutdown, metricC, aggC); err != nil {
+		if err := a.flusher(shutdown, a.metricC, a.aggC); err != nil {
 			log.Printf("E! Flusher routine failed, exiting: %s\n", err.Error())
 			close(shutdown)
 		}
 	}()
 
-	wg.Add(len(a.Config.Aggregators))
 	for _, aggregator := range a.Config.Aggregators {
-		go func(agg *models.RunningAggregator) {
-			defer wg.Done()
-			acc := NewAccumulator(agg, aggC)
-			acc.SetPrecision(a.Config.Agent.Precision.Duration,
-				a.Config.Agent.Interval.Duration)
-			agg.Run(acc, shutdown)
-		}(aggregator)
+		a.startAggregator(aggregator)
 	}
 
-	wg.Add(len(a.Config.Inputs))
 	for _, input := range a.Config.Inputs {
-		interval := a.Config.Agent.Interval.Duration
-		// overwrite global interval if this plugin has it's own.
-		if input.Config.Interval != 0 {
-			interval = input.Config.Interval
-		}
-		go func(in *models.RunningInput, interv time.Duration) {
-			defer wg.Done()
-			a.gatherer(shutdown, in, interv, metricC)
-		}(input, interval)
+		a.startGatherer(input, a.inputs[input])
 	}
+	a.taskMu.Unlock()
 
+	<-shutdown
+
+	a.taskMu.Lock()
+	var tasks []*task
+	for _, t := range a.inputs {
+		tasks = append(tasks, t)
+	}
+	for _, t := range a.aggregators {
+		tasks = append(tasks, t)
+	}
+	for _, t := range tasks {
+		close(t.stop)
+	}
+	for _, t := range tasks {
+		<-t.done
+	}
 	wg.Wait()
 	a.Close()
+
+	for input := range a.inputs {
+		stopService(input)
+		delete(a.inputs, input)
+	}
+	for aggregator := range a.aggregators {
+		delete(a.aggregators, aggregator)
+	}
+	return nil
+}
+
+// startService starts the input if it is a ServiceInput.
+func (a *Agent) startService(input *models.RunningInput) error {
+	input.SetDefaultTags(a.Config.Tags)
+	switch p := input.Input.(type) {
+	case telegraf.ServiceInput:
+		acc := NewAccumulator(input, a.metricC)
+		// Service input plugins should set their own precision of their
+		// metrics.
+		acc.SetPrecision(time.Nanosecond, 0)
+		return p.Start(acc)
+	}
 	return nil
 }
+
+func stopService(input *models.RunningInput) {
+	switch p := input.Input.(type) {
+	case telegraf.ServiceInput:
+		p.Stop()
+	}
+}
+
+// startGatherer gathers the input on its interval until the task is stopped.
+func (a *Agent) startGatherer(input *models.RunningInput, t *task) {
+	interval := a.Config.Agent.Interval.Duration
+	// overwrite global interval if this plugin has it's own.
+	if input.Config.Interval != 0 {
+		interval = input.Config.Interval
+	}
+	go func() {
+		defer close(t.done)
+		a.gatherer(t.stop, input, interval, a.metricC)
+	}()
+}
+
+func (a *Agent) startAggregator(agg *models.RunningAggregator) {
+	t := newTask()
+	a.aggregators[agg] = t
+	go func() {
+		defer close(t.done)
+		acc := NewAccumulator(agg, a.aggC)
+		acc.SetPrecision(a.Config.Agent.Precision.Duration,
+			a.Config.Agent.Interval.Duration)
+		agg.Run(acc, t.stop)
+	}()
+}
+
+// Reload applies a new configuration to the running agent. Plugins whose
+// configuration is unchanged keep running, so outputs keep their buffered
+// metrics and service inputs keep their state and listeners. Removed plugins
+// are stopped and added or changed plugins are started.
+//
+// If the [agent] or [global_tags] configuration changed, ErrRestartRequired is
+// returned and the running agent is left untouched. Removed and changed
+// outputs are closed before the new outputs are connected; if a new output
+// cannot be connected, an error is returned and the previous outputs are
+// restored, leaving the running agent untouched.
+func (a *Agent) Reload(c *config.Config) error {
+	if !a.Config.SameGlobalConfig(c) {
+		return ErrRestartRequired
+	}
+
+	a.taskMu.Lock()
+	defer a.taskMu.Unlock()
+
+	outputs, _, aggregators := a.plugins()
+	inputs := a.Config.Inputs
+	processors := a.Config.Processors
+
+	// Handle outputs first, so that a failing output aborts the reload
+	// before anything else is changed.
+	oMatch, oRemoved := match(outputSignatures(outputs), outputSignatures(c.Outputs))
+	kept := make([]*models.RunningOutput, 0, len(outputs))
+	for i, j := range oMatch {
+		if j >= 0 {
+			c.Outputs[i] = outputs[j]
+			kept = append(kept, outputs[j])
+		}
+	}
+	// A changed output may share its buffer directory or listener with its
+	// replacement, so it is written out and closed before the replacement
+	// is connected. A flush in progress is waited for, and the next one
+	// only writes the kept outputs.
+	a.flushMu.Lock()
+	a.mu.Lock()
+	a.Config.Outputs = kept
+	a.mu.Unlock()
+	for _, j := range oRemoved {
+		o := outputs[j]
+		if err := o.Write(); err != nil {
+			log.Printf("E! Error writing to removed output [%s]: %s\n", o.Name, err)
+		}
+		closeOutput(o)
+	}
+	a.flushMu.Unlock()
+
+	var added []*models.RunningOutput
+	for i, j := range oMatch {
+		if j >= 0 {
+			continue
+		}
+		if err := a.connectOutput(c.Outputs[i]); err != nil {
+			a.restoreOutputs(outputs, oRemoved, added)
+			return fmt.Errorf("output %s: %s", c.Outputs[i].Name, err)
+		}
+		added = append(added, c.Outputs[i])
+	}
+
+	pMatch, _ := match(processorSignatures(processors), processorSignatures(c.Processors))
+	for i, j := range pMatch {
+		if j >= 0 {
+			c.Processors[i] = processors[j]
+		}
+	}
+
+	aMatch, aRemoved := match(aggregatorSignatures(aggregators), aggregatorSignatures(c.Aggregators))
+	for i, j := range aMatch {
+		if j >= 0 {
+			c.Aggregators[i] = aggregators[j]
+		}
+	}
+
+	iMatch, iRemoved := match(inputSignatures(inputs), inputSignatures(c.Inputs))
+	for i, j := range iMatch {
+		if j >= 0 {
+			c.Inputs[i] = inputs[j]
+		}
+	}
+
+	a.mu.Lock()
+	a.Config.Outputs = c.Outputs
+	a.Config.Processors = c.Processors
+	a.Config.Aggregators = c.Aggregators
+	a.Config.Inputs = c.Inputs
+	a.mu.Unlock()
+
+	for _, j := range iRemoved {
+		input := inputs[j]
+		t := a.inputs[input]
+		close(t.stop)
+		<-t.done
+		stopService(input)
+		delete(a.inputs, input)
+	}
+
+	var err error
+	var failed []*models.RunningInput
+	for i, j := range iMatch {
+		if j >= 0 {
+			continue
+		}
+		input := c.Inputs[i]
+		if serr := a.startService(input); serr != nil {
+			log.Printf("E! Service for input %s failed to start: %s\n",
+				input.Name(), serr)
+			if err == nil {
+				err = fmt.Errorf("input %s: %s", input.Name(), serr)
+			}
+			failed = append(failed, input)
+			continue
+		}
+		t := newTask()
+		a.inputs[input] = t
+		a.startGatherer(input, t)
+	}
+	if len(failed) > 0 {
+		// Drop inputs that failed to start, they will be started again by
+		// the next reload.
+		running := make([]*models.RunningInput, 0, len(c.Inputs))
+		for _, input := range c.Inputs {
+			if _, ok := a.inputs[input]; ok {
+				running = append(running, input)
+			}
+		}
+		a.mu.Lock()
+		a.Config.Inputs = running
+		a.mu.Unlock()
+	}
+
+	for _, j := range aRemoved {
+		t := a.aggregators[aggregators[j]]
+		close(t.stop)
+		<-t.done
+		delete(a.aggregators, aggregators[j])
+	}
+	for i, j := range aMatch {
+		if j < 0 {
+			a.startAggregator(c.Aggregators[i])
+		}
+	}
+
+	log.Printf("I! Reloaded config: inputs +%d -%d, outputs +%d -%d, "+
+		"aggregators +%d -%d\n",
+		count(iMatch, -1), len(iRemoved), len(added), len(oRemoved),
+		count(aMatch, -1), len(aRemoved))
+	return err
+}
+
+// restoreOutputs undoes the output changes of a failed reload: the outputs
+// added so far are closed and the removed outputs are connected again.
+func (a *Agent) restoreOutputs(
+	outputs []*models.RunningOutput,
+	removed []int,
+	added []*models.RunningOutput,
+) {
+	for _, o := range added {
+		closeOutput(o)
+	}
+
+	restored := make([]*models.RunningOutput, 0, len(outputs))
+	for i, o := range outputs {
+		if count(removed, i) > 0 {
+			if err := a.connectOutput(o); err != nil {
+				log.Printf("E! Could not restore output %s: %s\n", o.Name, err)
+				continue
+			}
+		}
+		restored = append(restored, o)
+	}
+
+	a.mu.Lock()
+	a.Config.Outputs = restored
+	a.mu.Unlock()
+}
+
+// match pairs each new plugin with an unused old plugin of the same
+// signature. It returns, for each new plugin, the index of the old plugin it
+// replaces or -1, and the indexes of the old plugins left unmatched.
+func match(old, new []string) ([]int, []int) {
+	unused := make(map[string][]int)
+	for i, sig := range old {
+		unused[sig] = append(unused[sig], i)
+	}
+
+	matched := make([]int, len(new))
+	for i, sig := range new {
+		matched[i] = -1
+		if idx := unused[sig]; len(idx) > 0 {
+			matched[i] = idx[0]
+			unused[sig] = idx[1:]
+		}
+	}
+
+	var removed []int
+	for _, idx := range unused {
+		removed = append(removed, idx...)
+	}
+	sort.Ints(removed)
+	return matched, removed
+}
+
+func count(values []int, value int) int {
+	var n int
+	for _, v := range values {
+		if v == value {
+			n++
+		}
+	}
+	return n
+}
+
+func inputSignatures(inputs []*models.RunningInput) []string {
+	sigs := make([]string, len(inputs))
+	for i, input := range inputs {
+		sigs[i] = input.Config.Signature
+	}
+	return sigs
+}
+
+func outputSignatures(outputs []*models.RunningOutput) []string {
+	sigs := make([]string, len(outputs))
+	for i, output := range outputs {
+		sigs[i] = output.Config.Signature
+	}
+	return sigs
+}
+
+func aggregatorSignatures(aggregators []*models.RunningAggregator) []string {
+	sigs := make([]string, len(aggregators))
+	for i, aggregator := range aggregators {
+		sigs[i] = aggregator.Config.Signature
+	}
+	return sigs
+}
+
+func processorSignatures(processors models.RunningProcessors) []string {
+	sigs := make([]string, len(processors))
+	for i, processor := range processors {
+		sigs[i] = processor.Config.Signature
+	}
+	return sigs
+}
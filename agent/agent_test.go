@@ -1,9 +1,18 @@
 package agent
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/testutil"
 
 	// needing to load the plugins
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
@@ -11,6 +20,7 @@ import (
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAgent_OmitHostname(t *testing.T) {
@@ -109,3 +119,167 @@ func TestAgent_LoadOutput(t *testing.T) {
 	a, _ = NewAgent(c)
 	assert.Equal(t, 3, len(a.Config.Outputs))
 }
+
+func TestMatch(t *testing.T) {
+	matched, removed := match(
+		[]string{"a", "b", "a", "c"},
+		[]string{"a", "d", "a", "a", "c"},
+	)
+	assert.Equal(t, []int{0, -1, 2, -1, 3}, matched)
+	assert.Equal(t, []int{1}, removed)
+}
+
+type reloadInput struct {
+	Value int64
+}
+
+func (i *reloadInput) SampleConfig() string { return "" }
+func (i *reloadInput) Description() string  { return "" }
+func (i *reloadInput) Gather(acc telegraf.Accumulator) error {
+	acc.AddFields("reload", map[string]interface{}{"value": i.Value}, nil)
+	return nil
+}
+
+type reloadOutput struct {
+	Name string
+
+	metrics []telegraf.Metric
+}
+
+func (o *reloadOutput) SampleConfig() string { return "" }
+func (o *reloadOutput) Description() string  { return "" }
+func (o *reloadOutput) Connect() error       { return nil }
+func (o *reloadOutput) Close() error         { return nil }
+func (o *reloadOutput) Write(metrics []telegraf.Metric) error {
+	o.metrics = append(o.metrics, metrics...)
+	return nil
+}
+
+func init() {
+	inputs.Add("reload_test", func() telegraf.Input { return &reloadInput{} })
+	outputs.Add("reload_test", func() telegraf.Output { return &reloadOutput{} })
+}
+
+func loadReloadConfig(t *testing.T, contents string) *config.Config {
+	f, err := ioutil.TempFile("", "telegraf-reload")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(contents)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfig(f.Name()))
+	return c
+}
+
+const reloadAgentConfig = `
+[agent]
+  interval = "100ms"
+  flush_interval = "100ms"
+  round_interval = false
+`
+
+func TestAgent_Reload(t *testing.T) {
+	c := loadReloadConfig(t, reloadAgentConfig+`
+[[inputs.reload_test]]
+  value = 1
+[[inputs.reload_test]]
+  value = 2
+[[outputs.reload_test]]
+  name = "a"
+`)
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	require.NoError(t, a.Connect())
+
+	oldInput := a.Config.Inputs[0]
+	oldOutput := a.Config.Outputs[0]
+
+	shutdown := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		a.Run(shutdown)
+		close(done)
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	// whitespace and key order do not count as changes
+	err = a.Reload(loadReloadConfig(t, reloadAgentConfig+`
+[[inputs.reload_test]]
+  value = 1
+[[inputs.reload_test]]
+  value = 3
+
+[[outputs.reload_test]]
+  name =   "a"
+[[outputs.reload_test]]
+  name = "b"
+`))
+	require.NoError(t, err)
+
+	require.Len(t, a.Config.Inputs, 2)
+	assert.True(t, oldInput == a.Config.Inputs[0])
+	assert.Equal(t, int64(3), a.Config.Inputs[1].Input.(*reloadInput).Value)
+	require.Len(t, a.Config.Outputs, 2)
+	assert.True(t, oldOutput == a.Config.Outputs[0])
+	assert.Equal(t, "b", a.Config.Outputs[1].Output.(*reloadOutput).Name)
+	assert.Len(t, a.inputs, 2)
+
+	err = a.Reload(loadReloadConfig(t, `
+[agent]
+  interval = "1s"
+[[inputs.reload_test]]
+  value = 1
+[[outputs.reload_test]]
+  name = "a"
+`))
+	assert.Equal(t, ErrRestartRequired, err)
+	assert.Len(t, a.Config.Inputs, 2)
+
+	close(shutdown)
+	<-done
+}
+
+func TestAgent_ReloadBufferDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-reload")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	bufferDir := filepath.Join(dir, "buffer")
+	notADir := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(notADir, nil, 0644))
+
+	outputConfig := func(name, dir string) string {
+		return fmt.Sprintf(`
+[[outputs.reload_test]]
+  name = %q
+  buffer_directory = %q
+`, name, dir)
+	}
+
+	c := loadReloadConfig(t, reloadAgentConfig+outputConfig("a", bufferDir))
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	require.NoError(t, a.Connect())
+	defer a.Close()
+
+	// the changed output takes over the buffer directory of the old one
+	err = a.Reload(loadReloadConfig(t, reloadAgentConfig+outputConfig("b", bufferDir)))
+	require.NoError(t, err)
+	require.Len(t, a.Config.Outputs, 1)
+	output := a.Config.Outputs[0]
+	assert.Equal(t, "b", output.Output.(*reloadOutput).Name)
+
+	// an output that cannot be connected restores the previous outputs,
+	// reopening their disk buffer
+	err = a.Reload(loadReloadConfig(t, reloadAgentConfig+outputConfig("c", notADir)))
+	assert.Error(t, err)
+	require.Len(t, a.Config.Outputs, 1)
+	assert.True(t, output == a.Config.Outputs[0])
+
+	output.AddMetric(testutil.TestMetric(1, "restored"))
+	require.NoError(t, output.Write())
+	metrics := output.Output.(*reloadOutput).metrics
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "restored", metrics[0].Name())
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -31,6 +32,8 @@ var pprofAddr = flag.String("pprof-addr", "",
 var fQuiet = flag.Bool("quiet", false,
 	"run in quiet mode")
 var fTest = flag.Bool("test", false, "gather metrics, print them out, and exit")
+var fValidate = flag.Bool("validate", false,
+	"check the configuration for errors, print them out, and exit")
 var fConfig = flag.String("config", "", "configuration file to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
@@ -75,6 +78,42 @@ func init() {
 
 var stop chan struct{}
 
+// loadConfig loads and validates the configuration files given on the
+// command line.
+func loadConfig(inputFilters, outputFilters []string) (*config.Config, error) {
+	c := config.NewConfig()
+	c.OutputFilters = outputFilters
+	c.InputFilters = inputFilters
+	err := c.LoadConfig(*fConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if *fConfigDirectory != "" {
+		err = c.LoadDirectory(*fConfigDirectory)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !*fTest && len(c.Outputs) == 0 {
+		return nil, errors.New("Error: no outputs found, did you provide a valid config file?")
+	}
+	if len(c.Inputs) == 0 {
+		return nil, errors.New("Error: no inputs found, did you provide a valid config file?")
+	}
+
+	if int64(c.Agent.Interval.Duration) <= 0 {
+		return nil, fmt.Errorf("Agent interval must be positive, found %s",
+			c.Agent.Interval.Duration)
+	}
+
+	if int64(c.Agent.FlushInterval.Duration) <= 0 {
+		return nil, fmt.Errorf("Agent flush_interval must be positive; found %s",
+			c.Agent.Interval.Duration)
+	}
+	return c, nil
+}
+
 func reloadLoop(
 	stop chan struct{},
 	inputFilters []string,
@@ -88,37 +127,11 @@ func reloadLoop(
 		reload <- false
 
 		// If no other options are specified, load the config file and run.
-		c := config.NewConfig()
-		c.OutputFilters = outputFilters
-		c.InputFilters = inputFilters
-		err := c.LoadConfig(*fConfig)
+		c, err := loadConfig(inputFilters, outputFilters)
 		if err != nil {
 			log.Fatal("E! " + err.Error())
 		}
 
-		if *fConfigDirectory != "" {
-			err = c.LoadDirectory(*fConfigDirectory)
-			if err != nil {
-				log.Fatal("E! " + err.Error())
-			}
-		}
-		if !*fTest && len(c.Outputs) == 0 {
-			log.Fatalf("E! Error: no outputs found, did you provide a valid config file?")
-		}
-		if len(c.Inputs) == 0 {
-			log.Fatalf("E! Error: no inputs found, did you provide a valid config file?")
-		}
-
-		if int64(c.Agent.Interval.Duration) <= 0 {
-			log.Fatalf("E! Agent interval must be positive, found %s",
-				c.Agent.Interval.Duration)
-		}
-
-		if int64(c.Agent.FlushInterval.Duration) <= 0 {
-			log.Fatalf("E! Agent flush_interval must be positive; found %s",
-				c.Agent.Interval.Duration)
-		}
-
 		ag, err := agent.NewAgent(c)
 		if err != nil {
 			log.Fatal("E! " + err.Error())
@@ -145,22 +158,42 @@ func reloadLoop(
 		}
 
 		shutdown := make(chan struct{})
-		signals := make(chan os.Signal)
+		signals := make(chan os.Signal, 1)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP)
 		go func() {
-			select {
-			case sig := <-signals:
-				if sig == os.Interrupt {
-					close(shutdown)
-				}
-				if sig == syscall.SIGHUP {
-					log.Printf("I! Reloading Telegraf config\n")
-					<-reload
-					reload <- true
+			for {
+				select {
+				case sig := <-signals:
+					if sig == os.Interrupt {
+						close(shutdown)
+						return
+					}
+					if sig == syscall.SIGHUP {
+						log.Printf("I! Reloading Telegraf config\n")
+						nc, err := loadConfig(inputFilters, outputFilters)
+						if err != nil {
+							log.Printf("E! Not reloading, invalid config: %s\n", err)
+							continue
+						}
+
+						// Unchanged plugins keep running with their buffers,
+						// changes to the agent itself require a restart.
+						err = ag.Reload(nc)
+						if err == agent.ErrRestartRequired {
+							log.Printf("I! %s, restarting Telegraf\n", err)
+							<-reload
+							reload <- true
+							close(shutdown)
+							return
+						}
+						if err != nil {
+							log.Printf("E! Error reloading config: %s\n", err)
+						}
+					}
+				case <-stop:
 					close(shutdown)
+					return
 				}
-			case <-stop:
-				close(shutdown)
 			}
 		}()
 
@@ -188,6 +221,7 @@ func reloadLoop(
 		}
 
 		ag.Run(shutdown)
+		signal.Stop(signals)
 	}
 }
 
@@ -315,6 +349,19 @@ func main() {
 			log.Fatalf("E! %s and %s", err, err2)
 		}
 		return
+	case *fValidate:
+		errs := config.ValidateConfig(*fConfig)
+		if *fConfigDirectory != "" {
+			errs = append(errs, config.ValidateDirectory(*fConfigDirectory)...)
+		}
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if len(errs) > 0 {
+			os.Exit(1)
+		}
+		fmt.Println("Configuration is valid")
+		return
 	}
 
 	if runtime.GOOS == "windows" && !(*fRunAsConsole) {
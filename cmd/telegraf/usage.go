@@ -15,6 +15,7 @@ The commands & flags are:
 
   --config <file>     configuration file to load
   --test              gather metrics once, print them to stdout, and exit
+  --validate          check the configuration for errors and exit
   --config-directory  directory containing additional *.conf files
   --input-filter      filter the input plugins to enable, separator is :
   --output-filter     filter the output plugins to enable, separator is :
@@ -34,6 +35,9 @@ Examples:
   # run a single telegraf collection, outputing metrics to stdout
   telegraf --config telegraf.conf --test
 
+  # check a config file and its config directory for errors
+  telegraf --config telegraf.conf --config-directory telegraf.d --validate
+
   # run telegraf with all plugins defined in config file
   telegraf --config telegraf.conf
 
@@ -0,0 +1,504 @@
+package buffer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	serializer "github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+const (
+	// FsyncAlways syncs the buffer to disk every time metrics are added.
+	FsyncAlways = "always"
+	// FsyncInterval syncs the buffer to disk once per flush interval.
+	FsyncInterval = "interval"
+	// FsyncNever leaves syncing to the operating system.
+	FsyncNever = "never"
+
+	// Default maximum number of bytes kept on disk for each output.
+	DefaultDiskBufferMaxBytes = 100 * 1024 * 1024
+
+	// Segment files are rolled over once they reach this size.
+	maxSegmentBytes = 4 * 1024 * 1024
+
+	segmentExt = ".seg"
+	cursorFile = "cursor"
+)
+
+// position identifies a byte offset within a segment file.
+type position struct {
+	id     uint64
+	offset int64
+}
+
+// segment is a single append-only file of line protocol.
+type segment struct {
+	id    uint64
+	size  int64
+	count int // number of unacknowledged metrics in the segment
+}
+
+// DiskBuffer is a write-ahead buffer of metrics stored as line protocol in a
+// directory of segment files. Metrics are returned in the order they were
+// added and are only removed once acknowledged, so metrics that have not been
+// delivered are replayed after the agent restarts.
+//
+// Only the name, tags, fields and timestamp of a metric are persisted; the
+// value type is reset to untyped when the metric is read back.
+type DiskBuffer struct {
+	dir          string
+	maxBytes     int64
+	segmentBytes int64
+	fsync        string
+
+	serializer *serializer.Serializer
+	parser     *influx.Parser
+	lock       *dirLock
+
+	segments []*segment
+	tail     *os.File
+	cursor   position
+	size     int64
+	length   int
+
+	// State of the last call to Peek, applied by Ack.
+	peekStart  position
+	peekEnd    position
+	peekCounts map[uint64]int
+	peeked     bool
+
+	mu sync.Mutex
+}
+
+// NewDiskBuffer opens the buffer stored in dir, creating it if needed.
+// maxBytes is the maximum size of the buffer on disk; when it is exceeded the
+// oldest metrics are dropped. fsync is one of FsyncAlways, FsyncInterval or
+// FsyncNever. The directory is locked until the buffer is closed, so it cannot
+// be shared by two buffers.
+func NewDiskBuffer(dir string, maxBytes int64, fsync string) (*DiskBuffer, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultDiskBufferMaxBytes
+	}
+	switch fsync {
+	case "":
+		fsync = FsyncInterval
+	case FsyncAlways, FsyncInterval, FsyncNever:
+	default:
+		return nil, fmt.Errorf("invalid buffer fsync policy %q", fsync)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	lock, err := lockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := serializer.NewSerializer()
+	s.SetFieldTypeSupport(serializer.UintSupport)
+
+	b := &DiskBuffer{
+		dir:          dir,
+		maxBytes:     maxBytes,
+		segmentBytes: min64(maxSegmentBytes, maxBytes/4),
+		fsync:        fsync,
+		serializer:   s,
+		parser:       influx.NewParser(influx.NewMetricHandler()),
+		lock:         lock,
+	}
+	if err := b.open(); err != nil {
+		lock.unlock()
+		return nil, err
+	}
+	return b, nil
+}
+
+// open loads the segments and cursor found in the buffer directory.
+func (b *DiskBuffer) open() error {
+	cursor, err := b.readCursor()
+	if err != nil {
+		return err
+	}
+
+	files, err := ioutil.ReadDir(b.dir)
+	if err != nil {
+		return err
+	}
+
+	var ids []uint64
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for i, id := range ids {
+		if id < cursor.id {
+			// already acknowledged
+			if err := os.Remove(b.segmentPath(id)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var offset int64
+		if id == cursor.id {
+			offset = cursor.offset
+		}
+		seg, err := b.loadSegment(id, offset, i == len(ids)-1)
+		if err != nil {
+			return err
+		}
+		b.segments = append(b.segments, seg)
+		b.size += seg.size
+		b.length += seg.count
+	}
+
+	if len(b.segments) == 0 || b.segments[0].id != cursor.id {
+		cursor.offset = 0
+	}
+	if len(b.segments) > 0 {
+		cursor.id = b.segments[0].id
+	} else {
+		cursor.id++
+		b.segments = append(b.segments, &segment{id: cursor.id})
+	}
+	b.cursor = cursor
+
+	tail := b.segments[len(b.segments)-1]
+	b.tail, err = os.OpenFile(b.segmentPath(tail.id),
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	return err
+}
+
+// loadSegment counts the metrics in a segment after offset. A partially
+// written metric at the end of the last segment is truncated.
+func (b *DiskBuffer) loadSegment(id uint64, offset int64, last bool) (*segment, error) {
+	path := b.segmentPath(id)
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	end := bytes.LastIndexByte(contents, '\n') + 1
+	if end != len(contents) {
+		if !last {
+			return nil, fmt.Errorf("corrupt buffer segment %s", path)
+		}
+		log.Printf("W! Truncating incomplete metric in buffer segment %s", path)
+		if err := os.Truncate(path, int64(end)); err != nil {
+			return nil, err
+		}
+		contents = contents[:end]
+	}
+
+	seg := &segment{id: id, size: int64(len(contents))}
+	if offset < int64(len(contents)) {
+		seg.count = bytes.Count(contents[offset:], []byte{'\n'})
+	}
+	return seg, nil
+}
+
+func (b *DiskBuffer) segmentPath(id uint64) string {
+	return filepath.Join(b.dir, fmt.Sprintf("%020d%s", id, segmentExt))
+}
+
+func (b *DiskBuffer) readCursor() (position, error) {
+	var pos position
+	contents, err := ioutil.ReadFile(filepath.Join(b.dir, cursorFile))
+	if os.IsNotExist(err) {
+		return pos, nil
+	}
+	if err != nil {
+		return pos, err
+	}
+	_, err = fmt.Sscanf(string(contents), "%d %d", &pos.id, &pos.offset)
+	if err != nil {
+		return pos, fmt.Errorf("invalid buffer cursor in %s: %s", b.dir, err)
+	}
+	return pos, nil
+}
+
+// writeCursor atomically records the position of the oldest unacknowledged
+// metric.
+func (b *DiskBuffer) writeCursor() error {
+	path := filepath.Join(b.dir, cursorFile)
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, "%d %d\n", b.cursor.id, b.cursor.offset)
+	if err == nil && b.fsync != FsyncNever {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// IsEmpty returns true if DiskBuffer is empty.
+func (b *DiskBuffer) IsEmpty() bool {
+	return b.Len() == 0
+}
+
+// Len returns the number of unacknowledged metrics in the buffer.
+func (b *DiskBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.length
+}
+
+// Add appends metrics to the buffer. If the buffer grows beyond its maximum
+// size, the oldest segment of metrics is dropped.
+func (b *DiskBuffer) Add(metrics ...telegraf.Metric) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, m := range metrics {
+		MetricsWritten.Incr(1)
+		octets, err := b.serializer.Serialize(m)
+		if err != nil {
+			log.Printf("E! Could not buffer metric %s: %s", m.Name(), err)
+			MetricsDropped.Incr(1)
+			continue
+		}
+
+		tail := b.segments[len(b.segments)-1]
+		if tail.size > 0 && tail.size+int64(len(octets)) > b.segmentBytes {
+			if err := b.roll(); err != nil {
+				return err
+			}
+			tail = b.segments[len(b.segments)-1]
+		}
+
+		n, err := b.tail.Write(octets)
+		tail.size += int64(n)
+		b.size += int64(n)
+		if err != nil {
+			return err
+		}
+		tail.count++
+		b.length++
+	}
+
+	if b.fsync == FsyncAlways {
+		if err := b.tail.Sync(); err != nil {
+			return err
+		}
+	}
+
+	for b.size > b.maxBytes && len(b.segments) > 1 {
+		if err := b.dropOldest(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// roll closes the current tail segment and starts a new one.
+func (b *DiskBuffer) roll() error {
+	if b.fsync != FsyncNever {
+		if err := b.tail.Sync(); err != nil {
+			return err
+		}
+	}
+	if err := b.tail.Close(); err != nil {
+		return err
+	}
+
+	seg := &segment{id: b.segments[len(b.segments)-1].id + 1}
+	f, err := os.OpenFile(b.segmentPath(seg.id),
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	b.tail = f
+	b.segments = append(b.segments, seg)
+	return nil
+}
+
+// dropOldest removes the oldest segment, discarding its metrics. Metrics of
+// the segment returned by Peek are being written and are not counted as
+// dropped; the rest of the peeked batch can still be acknowledged.
+func (b *DiskBuffer) dropOldest() error {
+	head := b.segments[0]
+	var inflight int
+	if b.peeked {
+		inflight = b.peekCounts[head.id]
+		delete(b.peekCounts, head.id)
+	}
+	MetricsDropped.Incr(int64(head.count - inflight))
+	b.length -= head.count
+	b.size -= head.size
+	b.segments = b.segments[1:]
+	b.cursor = position{id: b.segments[0].id}
+	if b.peeked {
+		if b.peekEnd.id == head.id {
+			// the whole batch was in the dropped segment
+			b.peeked = false
+		} else {
+			b.peekStart = b.cursor
+		}
+	}
+	if err := b.writeCursor(); err != nil {
+		return err
+	}
+	return os.Remove(b.segmentPath(head.id))
+}
+
+// Peek returns up to batchSize of the oldest metrics in the buffer. The
+// metrics remain in the buffer until Ack is called. Lines that can no longer
+// be parsed are dropped.
+func (b *DiskBuffer) Peek(batchSize int) ([]telegraf.Metric, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.peeked = false
+	b.peekStart = b.cursor
+	b.peekEnd = b.cursor
+	b.peekCounts = make(map[uint64]int)
+
+	metrics := make([]telegraf.Metric, 0, min(b.length, batchSize))
+	for _, seg := range b.segments {
+		if len(metrics) >= batchSize {
+			break
+		}
+
+		var offset int64
+		if seg.id == b.cursor.id {
+			offset = b.cursor.offset
+		}
+		if offset >= seg.size {
+			continue
+		}
+
+		f, err := os.Open(b.segmentPath(seg.id))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		r := bufio.NewReader(io.LimitReader(f, seg.size-offset))
+		for len(metrics) < batchSize {
+			line, err := r.ReadBytes('\n')
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				f.Close()
+				return nil, err
+			}
+			offset += int64(len(line))
+			b.peekCounts[seg.id]++
+
+			m, err := b.parser.ParseLine(string(line[:len(line)-1]))
+			if err != nil {
+				log.Printf("E! Dropping unreadable metric from buffer %s: %s",
+					b.dir, err)
+				MetricsDropped.Incr(1)
+				continue
+			}
+			metrics = append(metrics, m)
+		}
+		f.Close()
+		b.peekEnd = position{id: seg.id, offset: offset}
+	}
+
+	b.peeked = true
+	return metrics, nil
+}
+
+// Ack removes the metrics returned by the last call to Peek from the buffer.
+func (b *DiskBuffer) Ack() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.peeked || b.peekStart != b.cursor {
+		// the peeked metrics were already dropped
+		return nil
+	}
+	b.peeked = false
+
+	for len(b.segments) > 0 {
+		head := b.segments[0]
+		if head.id > b.peekEnd.id {
+			break
+		}
+		head.count -= b.peekCounts[head.id]
+		b.length -= b.peekCounts[head.id]
+
+		if head.id < b.peekEnd.id ||
+			(b.peekEnd.offset >= head.size && len(b.segments) > 1) {
+			// fully consumed, and not the tail
+			b.size -= head.size
+			b.segments = b.segments[1:]
+			if err := os.Remove(b.segmentPath(head.id)); err != nil {
+				return err
+			}
+			b.cursor = position{id: b.segments[0].id}
+			continue
+		}
+		b.cursor = b.peekEnd
+		break
+	}
+	return b.writeCursor()
+}
+
+// Sync commits the buffer to disk when the fsync policy is FsyncInterval.
+func (b *DiskBuffer) Sync() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.fsync != FsyncInterval {
+		return nil
+	}
+	return b.tail.Sync()
+}
+
+// Close syncs and closes the buffer, releasing the lock on its directory.
+func (b *DiskBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer b.lock.unlock()
+	if b.fsync != FsyncNever {
+		if err := b.tail.Sync(); err != nil {
+			b.tail.Close()
+			return err
+		}
+	}
+	return b.tail.Close()
+}
+
+func min64(a, b int64) int64 {
+	if b < a {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,185 @@
+package buffer
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiskBuffer(t *testing.T, maxBytes int64) (*DiskBuffer, string) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	b, err := NewDiskBuffer(dir, maxBytes, FsyncAlways)
+	require.NoError(t, err)
+	return b, dir
+}
+
+func names(metrics []telegraf.Metric) []string {
+	var out []string
+	for _, m := range metrics {
+		out = append(out, m.Name())
+	}
+	return out
+}
+
+func TestDiskBufferPeekAck(t *testing.T) {
+	b, dir := newTestDiskBuffer(t, 0)
+	defer os.RemoveAll(dir)
+	defer b.Close()
+
+	assert.True(t, b.IsEmpty())
+	require.NoError(t, b.Add(metricList...))
+	assert.Equal(t, 5, b.Len())
+
+	batch, err := b.Peek(3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mymetric1", "mymetric2", "mymetric3"}, names(batch))
+	assert.Equal(t, 5, b.Len())
+
+	// without an Ack the same metrics are returned again
+	batch, err = b.Peek(3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mymetric1", "mymetric2", "mymetric3"}, names(batch))
+
+	require.NoError(t, b.Ack())
+	assert.Equal(t, 2, b.Len())
+
+	batch, err = b.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mymetric4", "mymetric5"}, names(batch))
+	require.NoError(t, b.Ack())
+	assert.True(t, b.IsEmpty())
+}
+
+func TestDiskBufferMetricValues(t *testing.T) {
+	b, dir := newTestDiskBuffer(t, 0)
+	defer os.RemoveAll(dir)
+	defer b.Close()
+
+	m := testutil.TestMetric(uint64(42), "mymetric")
+	m.AddField("string", "foo bar")
+	m.AddField("bool", true)
+	m.AddField("float", 1.5)
+	m.AddField("int", int64(-3))
+	require.NoError(t, b.Add(m))
+
+	batch, err := b.Peek(1)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	assert.Equal(t, m.Name(), batch[0].Name())
+	assert.Equal(t, m.Tags(), batch[0].Tags())
+	assert.Equal(t, m.Fields(), batch[0].Fields())
+	assert.Equal(t, m.Time().UnixNano(), batch[0].Time().UnixNano())
+}
+
+func TestDiskBufferReopen(t *testing.T) {
+	b, dir := newTestDiskBuffer(t, 0)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, b.Add(metricList...))
+	_, err := b.Peek(2)
+	require.NoError(t, err)
+	require.NoError(t, b.Ack())
+
+	// peeked but not acknowledged metrics are replayed after a restart
+	_, err = b.Peek(2)
+	require.NoError(t, err)
+	require.NoError(t, b.Close())
+
+	b, err = NewDiskBuffer(dir, 0, FsyncAlways)
+	require.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, 3, b.Len())
+
+	require.NoError(t, b.Add(testutil.TestMetric(1, "mymetric6")))
+	batch, err := b.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t,
+		[]string{"mymetric3", "mymetric4", "mymetric5", "mymetric6"},
+		names(batch))
+}
+
+func TestDiskBufferTruncatesPartialWrite(t *testing.T) {
+	b, dir := newTestDiskBuffer(t, 0)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, b.Add(metricList[:2]...))
+	_, err := b.tail.Write([]byte("mymetric3,tag1=val"))
+	require.NoError(t, err)
+	require.NoError(t, b.Close())
+
+	b, err = NewDiskBuffer(dir, 0, FsyncAlways)
+	require.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, 2, b.Len())
+
+	require.NoError(t, b.Add(metricList[2]))
+	batch, err := b.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mymetric1", "mymetric2", "mymetric3"}, names(batch))
+}
+
+func TestDiskBufferDropsOldestSegment(t *testing.T) {
+	// small enough that each segment holds a single metric
+	b, dir := newTestDiskBuffer(t, 200)
+	defer os.RemoveAll(dir)
+	defer b.Close()
+	MetricsDropped.Set(0)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, b.Add(metricList...))
+	}
+	assert.True(t, b.size <= 200)
+	assert.Equal(t, int64(10-b.Len()), MetricsDropped.Get())
+
+	batch, err := b.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, "mymetric5", batch[len(batch)-1].Name())
+	assert.Len(t, batch, b.Len())
+}
+
+func TestDiskBufferDropsPeekedSegment(t *testing.T) {
+	// small enough that each segment holds a single metric
+	b, dir := newTestDiskBuffer(t, 200)
+	defer os.RemoveAll(dir)
+	defer b.Close()
+
+	require.NoError(t, b.Add(metricList[:2]...))
+	batch, err := b.Peek(2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mymetric1", "mymetric2"}, names(batch))
+
+	// dropping the oldest segment while the batch is written must not
+	// resend the rest of the batch once it is acknowledged
+	require.NoError(t, b.Add(metricList[2:4]...))
+	require.NoError(t, b.Ack())
+
+	batch, err = b.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mymetric3", "mymetric4"}, names(batch))
+	assert.Equal(t, 2, b.Len())
+}
+
+func TestDiskBufferLocksDirectory(t *testing.T) {
+	b, dir := newTestDiskBuffer(t, 0)
+	defer os.RemoveAll(dir)
+
+	_, err := NewDiskBuffer(dir, 0, FsyncAlways)
+	assert.Error(t, err)
+
+	require.NoError(t, b.Close())
+	b, err = NewDiskBuffer(dir, 0, FsyncAlways)
+	require.NoError(t, err)
+	require.NoError(t, b.Close())
+}
+
+func TestDiskBufferInvalidFsync(t *testing.T) {
+	_, err := NewDiskBuffer(os.TempDir(), 0, "sometimes")
+	assert.Error(t, err)
+}
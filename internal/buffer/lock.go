@@ -0,0 +1,57 @@
+package buffer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const lockFile = "lock"
+
+var (
+	lockedMu   sync.Mutex
+	lockedDirs = make(map[string]bool)
+)
+
+// dirLock prevents two buffers from using the same directory at once.
+type dirLock struct {
+	dir  string
+	file *os.File
+}
+
+// lockDir takes the lock on a buffer directory. The lock is held by the
+// process for the directory and, where supported, by the lock file inside it
+// so that other processes cannot use the directory either.
+func lockDir(dir string) (*dirLock, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	lockedMu.Lock()
+	defer lockedMu.Unlock()
+	if lockedDirs[abs] {
+		return nil, fmt.Errorf("buffer directory %s is already in use", dir)
+	}
+
+	f, err := os.OpenFile(filepath.Join(abs, lockFile), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := flock(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("buffer directory %s is already in use: %s", dir, err)
+	}
+
+	lockedDirs[abs] = true
+	return &dirLock{dir: abs, file: f}, nil
+}
+
+// unlock releases the lock on the directory.
+func (l *dirLock) unlock() error {
+	lockedMu.Lock()
+	defer lockedMu.Unlock()
+	delete(lockedDirs, l.dir)
+	return l.file.Close()
+}
@@ -0,0 +1,14 @@
+// +build !windows,!solaris
+
+package buffer
+
+import (
+	"os"
+	"syscall"
+)
+
+// flock takes an exclusive advisory lock on f, which is released when f is
+// closed.
+func flock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
@@ -174,3 +174,33 @@ func TestConfig_LoadDirectory(t *testing.T) {
 	assert.Equal(t, pConfig, c.Inputs[3].Config,
 		"Merged Testdata did not produce correct procstat metadata.")
 }
+
+func TestConfig_Validate(t *testing.T) {
+	errs := ValidateConfig("./testdata/single_plugin.toml")
+	assert.Empty(t, errs)
+
+	errs = ValidateConfig("./testdata/invalid.toml")
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	assert.Equal(t, []string{
+		"./testdata/invalid.toml:3:14: agent: cannot unmarshal TOML integer into string",
+		"./testdata/invalid.toml:6:13: inputs.memcached: cannot unmarshal TOML string into []string",
+		"./testdata/invalid.toml:7:14: inputs.memcached: interval is not a valid duration: \"5 seconds\"",
+		"./testdata/invalid.toml:8:20: inputs.memcached: field corresponding to `no_such_option' is not defined in memcached.Memcached",
+		"./testdata/invalid.toml:10:1: undefined input plugin no_such_input",
+		"./testdata/invalid.toml:14:17: inputs.exec: Invalid data format: no_such_format",
+		"./testdata/invalid.toml:19:15: outputs.file: fieldpass must be an array of strings",
+	}, msgs)
+}
+
+func TestConfig_ValidateSyntaxError(t *testing.T) {
+	errs := ValidateConfig("./testdata/invalid_syntax.toml")
+	if assert.Len(t, errs, 1) {
+		verr, ok := errs[0].(*ValidationError)
+		if assert.True(t, ok) {
+			assert.Equal(t, 2, verr.Line)
+		}
+	}
+}
@@ -0,0 +1,517 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/influxdata/telegraf/plugins/aggregators"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/plugins/serializers"
+
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+)
+
+type optionKind int
+
+const (
+	stringOption optionKind = iota
+	durationOption
+	boolOption
+	intOption
+	stringArrayOption
+	tableOption
+)
+
+// Options handled by the agent rather than by the plugins, and the type of
+// value they expect.
+var (
+	filterOptions = map[string]optionKind{
+		"namepass":   stringArrayOption,
+		"namedrop":   stringArrayOption,
+		"fieldpass":  stringArrayOption,
+		"fielddrop":  stringArrayOption,
+		"pass":       stringArrayOption,
+		"drop":       stringArrayOption,
+		"tagexclude": stringArrayOption,
+		"taginclude": stringArrayOption,
+		"tagpass":    tableOption,
+		"tagdrop":    tableOption,
+	}
+
+	inputOptions = map[string]optionKind{
+		"interval":      durationOption,
+		"name_prefix":   stringOption,
+		"name_suffix":   stringOption,
+		"name_override": stringOption,
+		"tags":          tableOption,
+	}
+
+	outputOptions = map[string]optionKind{
+		"buffer_directory": stringOption,
+		"buffer_max_bytes": intOption,
+		"buffer_fsync":     stringOption,
+
+		"retry_initial_backoff": durationOption,
+		"retry_max_backoff":     durationOption,
+		"retry_jitter":          durationOption,
+		"retry_max_attempts":    intOption,
+	}
+
+	aggregatorOptions = map[string]optionKind{
+		"period":        durationOption,
+		"delay":         durationOption,
+		"drop_original": boolOption,
+		"name_prefix":   stringOption,
+		"name_suffix":   stringOption,
+		"name_override": stringOption,
+		"tags":          tableOption,
+	}
+
+	processorOptions = map[string]optionKind{
+		"order": intOption,
+	}
+
+	parserOptions = map[string]optionKind{
+		"data_format":                     stringOption,
+		"separator":                       stringOption,
+		"templates":                       stringArrayOption,
+		"tag_keys":                        stringArrayOption,
+		"json_string_fields":              stringArrayOption,
+		"json_name_key":                   stringOption,
+		"json_query":                      stringOption,
+		"json_time_key":                   stringOption,
+		"json_time_format":                stringOption,
+		"data_type":                       stringOption,
+		"collectd_auth_file":              stringOption,
+		"collectd_security_level":         stringOption,
+		"collectd_typesdb":                stringArrayOption,
+		"dropwizard_metric_registry_path": stringOption,
+		"dropwizard_time_path":            stringOption,
+		"dropwizard_time_format":          stringOption,
+		"dropwizard_tags_path":            stringOption,
+		"dropwizard_tag_paths":            tableOption,
+		"grok_patterns":                   stringArrayOption,
+		"grok_custom_patterns":            stringOption,
+		"grok_custom_pattern_files":       stringArrayOption,
+		"grok_timezone":                   stringOption,
+		"csv_header_row_count":            intOption,
+		"csv_skip_rows":                   intOption,
+		"csv_skip_columns":                intOption,
+		"csv_delimiter":                   stringOption,
+		"csv_comment":                     stringOption,
+		"csv_trim_space":                  boolOption,
+		"csv_column_names":                stringArrayOption,
+		"csv_column_types":                stringArrayOption,
+		"csv_tag_columns":                 stringArrayOption,
+		"csv_measurement_column":          stringOption,
+		"csv_timestamp_column":            stringOption,
+		"csv_timestamp_format":            stringOption,
+	}
+
+	serializerOptions = map[string]optionKind{
+		"data_format":           stringOption,
+		"prefix":                stringOption,
+		"template":              stringOption,
+		"influx_max_line_bytes": intOption,
+		"influx_sort_fields":    boolOption,
+		"influx_uint_support":   boolOption,
+		"json_timestamp_units":  durationOption,
+
+		"prometheus_export_timestamp": boolOption,
+		"prometheus_string_as_label":  boolOption,
+
+		"splunkmetric_hec_routing": boolOption,
+	}
+)
+
+// ValidationError is a problem found in a configuration file, located at the
+// offending key or table.
+type ValidationError struct {
+	File   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Err)
+}
+
+// ValidateConfig checks the configuration file at path without starting any
+// plugins, and returns every problem found: syntax errors, undefined plugins,
+// unknown keys, values of the wrong type, invalid filters and invalid
+// data_format options.
+func ValidateConfig(path string) []error {
+	var err error
+	if path == "" {
+		if path, err = getDefaultConfigPath(); err != nil {
+			return []error{err}
+		}
+	}
+
+	contents, err := readFile(path)
+	if err != nil {
+		return []error{err}
+	}
+
+	tbl, err := toml.Parse(contents)
+	if err != nil {
+		if lerr, ok := err.(*toml.LineError); ok {
+			return []error{&ValidationError{File: path, Line: lerr.Line, Err: lerr.Err}}
+		}
+		return []error{fmt.Errorf("%s: %s", path, err)}
+	}
+
+	v := &validator{file: path, data: []rune(string(contents))}
+	v.validate(tbl)
+	sort.SliceStable(v.errs, func(i, j int) bool {
+		a, b := v.errs[i].(*ValidationError), v.errs[j].(*ValidationError)
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return v.errs
+}
+
+// ValidateDirectory checks all *.conf files in the directory, see
+// ValidateConfig.
+func ValidateDirectory(path string) []error {
+	var errs []error
+	err := walkDirectory(path, func(path string) error {
+		errs = append(errs, ValidateConfig(path)...)
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+type validator struct {
+	file string
+	data []rune
+	errs []error
+}
+
+// addError records err at the position of node, which is an *ast.KeyValue,
+// an *ast.Table or a []*ast.Table.
+func (v *validator) addError(node interface{}, err error) {
+	verr := &ValidationError{File: v.file, Err: err}
+	if lerr, ok := err.(*toml.LineError); ok {
+		verr.Line = lerr.Line
+		verr.Err = lerr.Err
+	}
+
+	switch n := node.(type) {
+	case *ast.KeyValue:
+		verr.Line = n.Line
+		verr.Column = v.column(n.Value.Pos())
+	case *ast.Table:
+		verr.Line = n.Line
+		verr.Column = v.column(n.Position.Begin)
+	case []*ast.Table:
+		if len(n) > 0 {
+			verr.Line = n[0].Line
+			verr.Column = v.column(n[0].Position.Begin)
+		}
+	}
+	v.errs = append(v.errs, verr)
+}
+
+// column returns the column of the character at offset pos.
+func (v *validator) column(pos int) int {
+	if pos < 0 || pos > len(v.data) {
+		return 0
+	}
+	col := 1
+	for i := pos - 1; i >= 0 && v.data[i] != '\n'; i-- {
+		col++
+	}
+	return col
+}
+
+func (v *validator) validate(tbl *ast.Table) {
+	for _, name := range sortedKeys(tbl) {
+		val := tbl.Fields[name]
+		subTable, ok := val.(*ast.Table)
+		if !ok {
+			v.addError(val, fmt.Errorf("invalid configuration, %s must be a table", name))
+			continue
+		}
+
+		switch name {
+		case "agent":
+			v.validateKeys(subTable, "agent", &AgentConfig{})
+		case "global_tags", "tags":
+			for _, key := range sortedKeys(subTable) {
+				if err := checkOption(subTable.Fields[key], stringOption); err != nil {
+					v.addError(subTable.Fields[key], fmt.Errorf("%s: %s", key, err))
+				}
+			}
+		case "outputs", "inputs", "plugins", "processors", "aggregators":
+			kind := name
+			if kind == "plugins" {
+				kind = "inputs"
+			}
+			for _, pluginName := range sortedKeys(subTable) {
+				switch pluginSubTable := subTable.Fields[pluginName].(type) {
+				case *ast.Table:
+					if kind == "processors" || kind == "aggregators" {
+						v.addError(pluginSubTable, fmt.Errorf(
+							"%s.%s must be an array of tables, ie [[%s.%s]]",
+							kind, pluginName, kind, pluginName))
+						continue
+					}
+					v.validatePlugin(kind, pluginName, pluginSubTable)
+				case []*ast.Table:
+					for _, t := range pluginSubTable {
+						v.validatePlugin(kind, pluginName, t)
+					}
+				default:
+					v.addError(pluginSubTable, fmt.Errorf(
+						"unsupported config format: %s", pluginName))
+				}
+			}
+		default:
+			// legacy input
+			v.validatePlugin("inputs", name, subTable)
+		}
+	}
+}
+
+// validatePlugin checks a single plugin table, going through the same steps
+// as when the plugin is added to the configuration.
+func (v *validator) validatePlugin(kind, name string, tbl *ast.Table) {
+	plugin := kind + "." + name
+	var target interface{}
+	switch kind {
+	case "inputs":
+		if name == "io" {
+			name = "diskio"
+		}
+		creator, ok := inputs.Inputs[name]
+		if !ok {
+			v.addError(tbl, fmt.Errorf("undefined input plugin %s", name))
+			return
+		}
+		input := creator()
+		target = input
+
+		v.checkOptions(plugin, tbl, inputOptions)
+		v.checkOptions(plugin, tbl, filterOptions)
+		if _, ok := input.(parsers.ParserInput); ok {
+			v.checkOptions(plugin, tbl, parserOptions)
+			node := nodeOf(tbl, "data_format")
+			if _, err := buildParser(name, tbl); err != nil {
+				v.addError(node, fmt.Errorf("%s: %s", plugin, err))
+			}
+		}
+		node := filterNode(tbl)
+		if _, err := buildInput(name, tbl); err != nil {
+			v.addError(node, fmt.Errorf("%s: %s", plugin, err))
+		}
+	case "outputs":
+		creator, ok := outputs.Outputs[name]
+		if !ok {
+			v.addError(tbl, fmt.Errorf("undefined output plugin %s", name))
+			return
+		}
+		output := creator()
+		target = output
+
+		v.checkOptions(plugin, tbl, outputOptions)
+		v.checkOptions(plugin, tbl, filterOptions)
+		if _, ok := output.(serializers.SerializerOutput); ok {
+			v.checkOptions(plugin, tbl, serializerOptions)
+			node := nodeOf(tbl, "data_format")
+			if _, err := buildSerializer(name, tbl); err != nil {
+				v.addError(node, fmt.Errorf("%s: %s", plugin, err))
+			}
+		}
+		node := filterNode(tbl)
+		if _, err := buildOutput(name, tbl); err != nil {
+			v.addError(node, fmt.Errorf("%s: %s", plugin, err))
+		}
+	case "processors":
+		creator, ok := processors.Processors[name]
+		if !ok {
+			v.addError(tbl, fmt.Errorf("undefined processor plugin %s", name))
+			return
+		}
+		target = creator()
+
+		v.checkOptions(plugin, tbl, processorOptions)
+		v.checkOptions(plugin, tbl, filterOptions)
+		node := filterNode(tbl)
+		if _, err := buildProcessor(name, tbl); err != nil {
+			v.addError(node, fmt.Errorf("%s: %s", plugin, err))
+			return
+		}
+	case "aggregators":
+		creator, ok := aggregators.Aggregators[name]
+		if !ok {
+			v.addError(tbl, fmt.Errorf("undefined aggregator plugin %s", name))
+			return
+		}
+		target = creator()
+
+		v.checkOptions(plugin, tbl, aggregatorOptions)
+		v.checkOptions(plugin, tbl, filterOptions)
+		node := filterNode(tbl)
+		if _, err := buildAggregator(name, tbl); err != nil {
+			v.addError(node, fmt.Errorf("%s: %s", plugin, err))
+			return
+		}
+	}
+
+	// Only the plugin's own options are left in the table at this point.
+	v.validateKeys(tbl, plugin, target)
+}
+
+// checkOptions checks the type of the agent handled options present in the
+// table. Options of the wrong type are reported and removed from the table,
+// so they are not reported again when the table is built.
+func (v *validator) checkOptions(plugin string, tbl *ast.Table, options map[string]optionKind) {
+	for _, key := range sortedKeys(tbl) {
+		kind, ok := options[key]
+		if !ok {
+			continue
+		}
+		node := tbl.Fields[key]
+		if err := checkOption(node, kind); err != nil {
+			v.addError(node, fmt.Errorf("%s: %s %s", plugin, key, err))
+			delete(tbl.Fields, key)
+		}
+	}
+}
+
+// validateKeys unmarshals each key of the table on its own, so that every
+// unknown key and value of the wrong type is reported instead of only the
+// first one.
+func (v *validator) validateKeys(tbl *ast.Table, plugin string, target interface{}) {
+	for _, key := range sortedKeys(tbl) {
+		node := tbl.Fields[key]
+		single := &ast.Table{
+			Position: tbl.Position,
+			Line:     tbl.Line,
+			Name:     tbl.Name,
+			Fields:   map[string]interface{}{key: node},
+			Type:     tbl.Type,
+		}
+		if err := toml.UnmarshalTable(single, target); err != nil {
+			if lerr, ok := err.(*toml.LineError); ok {
+				err = lerr.Err
+			}
+			v.addError(node, fmt.Errorf("%s: %s", plugin, err))
+		}
+	}
+}
+
+func checkOption(node interface{}, kind optionKind) error {
+	if kind == tableOption {
+		tbl, ok := node.(*ast.Table)
+		if !ok {
+			return fmt.Errorf("must be a table")
+		}
+		for _, key := range sortedKeys(tbl) {
+			// tagpass and tagdrop hold arrays, tags hold strings
+			err := checkOption(tbl.Fields[key], stringArrayOption)
+			if err != nil {
+				err = checkOption(tbl.Fields[key], stringOption)
+			}
+			if err != nil {
+				return fmt.Errorf("must only contain strings or arrays of strings")
+			}
+		}
+		return nil
+	}
+
+	kv, ok := node.(*ast.KeyValue)
+	if !ok {
+		return fmt.Errorf("must be a value, not a table")
+	}
+
+	switch kind {
+	case stringOption:
+		if _, ok := kv.Value.(*ast.String); !ok {
+			return fmt.Errorf("must be a string")
+		}
+	case durationOption:
+		str, ok := kv.Value.(*ast.String)
+		if !ok {
+			return fmt.Errorf("must be a duration string, ie \"10s\"")
+		}
+		if _, err := time.ParseDuration(str.Value); err != nil {
+			return fmt.Errorf("is not a valid duration: %q", str.Value)
+		}
+	case boolOption:
+		if _, ok := kv.Value.(*ast.Boolean); !ok {
+			return fmt.Errorf("must be a boolean")
+		}
+	case intOption:
+		if _, ok := kv.Value.(*ast.Integer); !ok {
+			return fmt.Errorf("must be an integer")
+		}
+	case stringArrayOption:
+		ary, ok := kv.Value.(*ast.Array)
+		if !ok {
+			return fmt.Errorf("must be an array of strings")
+		}
+		for _, elem := range ary.Value {
+			if _, ok := elem.(*ast.String); !ok {
+				return fmt.Errorf("must be an array of strings")
+			}
+		}
+	}
+	return nil
+}
+
+// nodeOf returns the node of key if present, otherwise the table itself.
+func nodeOf(tbl *ast.Table, key string) interface{} {
+	if node, ok := tbl.Fields[key]; ok {
+		return node
+	}
+	return tbl
+}
+
+// filterNode returns the first filter option of the table, which is where
+// filter compilation errors are reported.
+func filterNode(tbl *ast.Table) interface{} {
+	var first interface{} = tbl
+	line := -1
+	for key := range filterOptions {
+		node, ok := tbl.Fields[key]
+		if !ok {
+			continue
+		}
+		var l int
+		switch n := node.(type) {
+		case *ast.KeyValue:
+			l = n.Line
+		case *ast.Table:
+			l = n.Line
+		}
+		if line == -1 || l < line {
+			first, line = node, l
+		}
+	}
+	return first
+}
+
+func sortedKeys(tbl *ast.Table) []string {
+	keys := make([]string, 0, len(tbl.Fields))
+	for key := range tbl.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
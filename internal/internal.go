@@ -3,11 +3,13 @@ package internal
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
 	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"math/big"
@@ -262,6 +264,24 @@ func WaitTimeout(c *exec.Cmd, timeout time.Duration) error {
 	}
 }
 
+// CompressWithGzip takes an io.Reader as input and pipes it through a
+// gzip.Writer, returning an io.Reader containing the gzipped data.  An error
+// while reading the input is returned when reading the output.
+func CompressWithGzip(data io.Reader) io.Reader {
+	pr, pw := io.Pipe()
+	gw := gzip.NewWriter(pw)
+
+	go func() {
+		_, err := io.Copy(gw, data)
+		if err == nil {
+			err = gw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return pr
+}
+
 // RandomSleep will sleep for a random amount of time up to max.
 // If the shutdown channel is closed, it will return before it has finished
 // sleeping.
@@ -285,3 +305,63 @@ func RandomSleep(max time.Duration, shutdown chan struct{}) {
 		return
 	}
 }
+
+// ParseTimestamp parses a timestamp with the given format, which is one of
+// "unix", "unix_ms", "unix_us" and "unix_ns" for numbers of seconds,
+// milliseconds, microseconds and nanoseconds since the epoch, or else a Go
+// time layout.  Unix timestamps can be numbers or strings, and may contain a
+// decimal part.
+func ParseTimestamp(timestamp interface{}, format string) (time.Time, error) {
+	switch format {
+	case "unix":
+		return parseUnixTimestamp(timestamp, time.Second)
+	case "unix_ms":
+		return parseUnixTimestamp(timestamp, time.Millisecond)
+	case "unix_us":
+		return parseUnixTimestamp(timestamp, time.Microsecond)
+	case "unix_ns":
+		return parseUnixTimestamp(timestamp, time.Nanosecond)
+	}
+
+	str, ok := timestamp.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("unsupported type %T for timestamp %v", timestamp, timestamp)
+	}
+	return time.Parse(format, str)
+}
+
+func parseUnixTimestamp(timestamp interface{}, unit time.Duration) (time.Time, error) {
+	switch ts := timestamp.(type) {
+	case int64:
+		return time.Unix(0, ts*int64(unit)).UTC(), nil
+	case uint64:
+		return time.Unix(0, int64(ts)*int64(unit)).UTC(), nil
+	case float64:
+		sec := int64(ts)
+		frac := ts - float64(sec)
+		return time.Unix(0, sec*int64(unit)+int64(frac*float64(unit))).UTC(), nil
+	case string:
+		// The decimal part is parsed separately, to avoid the rounding
+		// errors of floats.
+		parts := strings.SplitN(ts, ".", 2)
+		i, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid unix timestamp %q", ts)
+		}
+		var frac int64
+		if len(parts) == 2 {
+			// Pad or cut the decimals to billionths of the unit.
+			digits := (parts[1] + "000000000")[:9]
+			frac, err = strconv.ParseInt(digits, 10, 64)
+			if err != nil || frac < 0 {
+				return time.Time{}, fmt.Errorf("invalid unix timestamp %q", ts)
+			}
+			frac = frac * int64(unit) / 1e9
+			if strings.HasPrefix(parts[0], "-") {
+				frac = -frac
+			}
+		}
+		return time.Unix(0, i*int64(unit)+frac).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("unsupported type %T for timestamp %v", timestamp, timestamp)
+}
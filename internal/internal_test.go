@@ -1,6 +1,9 @@
 package internal
 
 import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
 	"os/exec"
 	"testing"
 	"time"
@@ -162,3 +165,52 @@ func TestDuration(t *testing.T) {
 	d.UnmarshalTOML([]byte(`1.5`))
 	assert.Equal(t, time.Second, d.Duration)
 }
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		timestamp interface{}
+		format    string
+		expected  time.Time
+	}{
+		{int64(1536092344), "unix", time.Unix(1536092344, 0)},
+		{float64(1536092344.5), "unix", time.Unix(1536092344, 500000000)},
+		{"1536092344", "unix", time.Unix(1536092344, 0)},
+		{"1536092344.159", "unix", time.Unix(1536092344, 159000000)},
+		{"1536092344159.123", "unix_ms", time.Unix(1536092344, 159123000)},
+		{float64(1536092344159), "unix_ms", time.Unix(1536092344, 159000000)},
+		{"1536092344159", "unix_ms", time.Unix(1536092344, 159000000)},
+		{"1536092344159123", "unix_us", time.Unix(1536092344, 159123000)},
+		{"1536092344159123456", "unix_ns", time.Unix(1536092344, 159123456)},
+		{"2018-09-04T20:19:04Z", time.RFC3339, time.Unix(1536092344, 0)},
+		{"04 Sep 18 22:19 +0200", time.RFC822Z, time.Unix(1536092340, 0)},
+	}
+
+	for _, tt := range tests {
+		ts, err := ParseTimestamp(tt.timestamp, tt.format)
+		assert.NoError(t, err)
+		assert.True(t, tt.expected.Equal(ts), "%v %s: expected %s, got %s",
+			tt.timestamp, tt.format, tt.expected, ts)
+	}
+
+	_, err := ParseTimestamp("yesterday", "unix")
+	assert.Error(t, err)
+	_, err = ParseTimestamp(true, "unix_ms")
+	assert.Error(t, err)
+	_, err = ParseTimestamp(float64(1536092344), time.RFC3339)
+	assert.Error(t, err)
+}
+
+func TestCompressWithGzip(t *testing.T) {
+	testData := "the quick brown fox jumps over the lazy dog"
+	inputBuffer := bytes.NewBuffer([]byte(testData))
+
+	outputBuffer := CompressWithGzip(inputBuffer)
+	gzipReader, err := gzip.NewReader(outputBuffer)
+	assert.NoError(t, err)
+	defer gzipReader.Close()
+
+	output, err := ioutil.ReadAll(gzipReader)
+	assert.NoError(t, err)
+
+	assert.Equal(t, testData, string(output))
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const (
+	// Default retry policy of outputs.
+	DEFAULT_RETRY_INITIAL_BACKOFF = time.Second
+	DEFAULT_RETRY_MAX_BACKOFF     = time.Minute
+	DEFAULT_RETRY_MAX_ATTEMPTS    = 3
+)
+
+// BreakerState is the state of a CircuitBreaker, as reported by the
+// breaker_state field of the internal_write measurement.
+type BreakerState int64
+
+const (
+	// BreakerClosed lets writes through, backing off after each failure.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects all writes while the output is reconnected.
+	BreakerOpen
+	// BreakerHalfOpen lets a single trial write through after a reconnect.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// RetryConfig is the retry policy of an output.
+type RetryConfig struct {
+	// InitialBackoff is the delay after the first failure, it is doubled
+	// after each consecutive failure up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter is the maximum random delay added to each backoff.
+	Jitter time.Duration
+	// MaxAttempts is the number of consecutive failures opening the breaker.
+	MaxAttempts int
+}
+
+// CircuitBreaker tracks the failures of an output. While closed, a failed
+// write delays the next one by an exponential backoff. Once MaxAttempts
+// consecutive attempts failed, the breaker opens and rejects writes until the
+// output has been reconnected, after which it is half-open: the next write
+// closes it on success and opens it again on failure.
+type CircuitBreaker struct {
+	policy RetryConfig
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	next     time.Time
+
+	// now and after are the clock of the breaker, replaced by tests.
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+
+	State selfstat.Stat
+}
+
+// NewCircuitBreaker returns a closed breaker, reporting its state through
+// stat.
+func NewCircuitBreaker(policy RetryConfig, stat selfstat.Stat) *CircuitBreaker {
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = DEFAULT_RETRY_INITIAL_BACKOFF
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = DEFAULT_RETRY_MAX_BACKOFF
+	}
+	if policy.MaxBackoff < policy.InitialBackoff {
+		policy.MaxBackoff = policy.InitialBackoff
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DEFAULT_RETRY_MAX_ATTEMPTS
+	}
+	return &CircuitBreaker{
+		policy: policy,
+		now:    time.Now,
+		after:  time.After,
+		State:  stat,
+	}
+}
+
+// Allow returns true if a write may be attempted now.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		return false
+	case BreakerClosed:
+		return !b.now().Before(b.next)
+	}
+	return true
+}
+
+// Success records a successful write and closes the breaker.
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.next = time.Time{}
+	b.setState(BreakerClosed)
+}
+
+// Failure records a failed write and returns true if it opened the breaker.
+func (b *CircuitBreaker) Failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen {
+		return false
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.policy.MaxAttempts {
+		b.setState(BreakerOpen)
+		return true
+	}
+	b.next = b.now().Add(b.Backoff(b.failures))
+	return false
+}
+
+// Open opens the breaker, ie when the output could not be connected. It
+// returns false if the breaker was already open.
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen {
+		return false
+	}
+	b.setState(BreakerOpen)
+	return true
+}
+
+// HalfOpen lets a trial write through once the output has been reconnected.
+func (b *CircuitBreaker) HalfOpen() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setState(BreakerHalfOpen)
+}
+
+// Current returns the current state of the breaker.
+func (b *CircuitBreaker) Current() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// MaxAttempts returns the number of attempts made before giving up.
+func (b *CircuitBreaker) MaxAttempts() int {
+	return b.policy.MaxAttempts
+}
+
+// Backoff returns the delay before the attempt following the given number of
+// consecutive failures.
+func (b *CircuitBreaker) Backoff(failures int) time.Duration {
+	backoff := b.policy.InitialBackoff
+	for i := 1; i < failures && backoff < b.policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > b.policy.MaxBackoff {
+		backoff = b.policy.MaxBackoff
+	}
+	if b.policy.Jitter > 0 {
+		backoff += time.Duration(rand.Int63n(int64(b.policy.Jitter)))
+	}
+	return backoff
+}
+
+// Wait returns a channel receiving the time once the given backoff elapsed.
+func (b *CircuitBreaker) Wait(backoff time.Duration) <-chan time.Time {
+	return b.after(backoff)
+}
+
+func (b *CircuitBreaker) setState(state BreakerState) {
+	b.state = state
+	b.State.Set(int64(state))
+}
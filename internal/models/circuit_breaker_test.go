@@ -0,0 +1,112 @@
+package models
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/selfstat"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockClock is a manual clock for circuit breakers. The timers started by
+// After only fire when the test calls Fire.
+type mockClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers chan chan time.Time
+}
+
+func newMockClock(b *CircuitBreaker) *mockClock {
+	c := &mockClock{
+		now:    time.Unix(0, 0),
+		timers: make(chan chan time.Time, 10),
+	}
+	b.now = c.Now
+	b.after = c.After
+	return c
+}
+
+func (c *mockClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *mockClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *mockClock) After(d time.Duration) <-chan time.Time {
+	timer := make(chan time.Time, 1)
+	c.timers <- timer
+	return timer
+}
+
+// Next waits for the next timer to be started.
+func (c *mockClock) Next() chan time.Time {
+	return <-c.timers
+}
+
+// Fire waits for the next timer to be started and fires it.
+func (c *mockClock) Fire() {
+	c.Next() <- c.Now()
+}
+
+func TestCircuitBreakerBackoff(t *testing.T) {
+	b := NewCircuitBreaker(RetryConfig{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+	}, selfstat.Register("test", "breaker_state", map[string]string{}))
+
+	assert.Equal(t, time.Second, b.Backoff(1))
+	assert.Equal(t, 2*time.Second, b.Backoff(2))
+	assert.Equal(t, 4*time.Second, b.Backoff(3))
+	assert.Equal(t, 5*time.Second, b.Backoff(4))
+	assert.Equal(t, 5*time.Second, b.Backoff(100))
+}
+
+func TestCircuitBreakerJitter(t *testing.T) {
+	b := NewCircuitBreaker(RetryConfig{
+		InitialBackoff: time.Second,
+		Jitter:         time.Second,
+	}, selfstat.Register("test", "breaker_state", map[string]string{}))
+
+	for i := 0; i < 10; i++ {
+		backoff := b.Backoff(1)
+		assert.True(t, backoff >= time.Second && backoff < 2*time.Second)
+	}
+}
+
+func TestCircuitBreakerStates(t *testing.T) {
+	stat := selfstat.Register("test", "breaker_state", map[string]string{})
+	b := NewCircuitBreaker(RetryConfig{
+		InitialBackoff: time.Hour,
+		MaxAttempts:    2,
+	}, stat)
+
+	clock := newMockClock(b)
+
+	assert.True(t, b.Allow())
+	assert.False(t, b.Failure())
+	assert.False(t, b.Allow(), "backing off after a failure")
+	clock.Add(time.Hour)
+	assert.True(t, b.Allow(), "backoff elapsed")
+	assert.True(t, b.Failure())
+	assert.Equal(t, BreakerOpen, b.Current())
+	assert.Equal(t, int64(BreakerOpen), stat.Get())
+	assert.False(t, b.Failure(), "already open")
+
+	b.HalfOpen()
+	assert.True(t, b.Allow())
+	assert.True(t, b.Failure(), "a failed trial write opens the breaker")
+
+	b.HalfOpen()
+	b.Success()
+	assert.Equal(t, BreakerClosed, b.Current())
+	assert.True(t, b.Allow())
+	assert.Equal(t, int64(BreakerClosed), stat.Get())
+}
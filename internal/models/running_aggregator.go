@@ -42,6 +42,9 @@ type AggregatorConfig struct {
 
 	Period time.Duration
 	Delay  time.Duration
+
+	// Signature identifies the configuration the aggregator was built from.
+	Signature string
 }
 
 func (r *RunningAggregator) Name() string {
@@ -45,6 +45,9 @@ type InputConfig struct {
 	Tags              map[string]string
 	Filter            Filter
 	Interval          time.Duration
+
+	// Signature identifies the configuration the input was built from.
+	Signature string
 }
 
 func (r *RunningInput) Name() string {
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"log"
 	"sync"
 	"time"
@@ -33,13 +34,27 @@ type RunningOutput struct {
 	BufferLimit     selfstat.Stat
 	WriteTime       selfstat.Stat
 
+	breaker *CircuitBreaker
+
 	metrics     *buffer.Buffer
 	failMetrics *buffer.Buffer
 
+	// diskBuffer replaces both in-memory buffers when the output is
+	// configured with a buffer_directory.
+	diskBuffer *buffer.DiskBuffer
+
+	// connected is set once Connect succeeded, and guarded by the Mutex.
+	connected bool
+	stop      chan struct{}
+	wg        sync.WaitGroup
+
 	// Guards against concurrent calls to the Output as described in #3009
 	sync.Mutex
 }
 
+// errBackoff is returned when a write is skipped by the circuit breaker.
+var errBackoff = errors.New("output is backing off after a failure")
+
 func NewRunningOutput(
 	name string,
 	output telegraf.Output,
@@ -59,6 +74,7 @@ func NewRunningOutput(
 		failMetrics:       buffer.NewBuffer(bufferLimit),
 		Output:            output,
 		Config:            conf,
+		stop:              make(chan struct{}),
 		MetricBufferLimit: bufferLimit,
 		MetricBatchSize:   batchSize,
 		MetricsWritten: selfstat.Register(
@@ -87,10 +103,104 @@ func NewRunningOutput(
 			map[string]string{"output": name},
 		),
 	}
+	ro.breaker = NewCircuitBreaker(conf.Retry, selfstat.Register(
+		"write",
+		"breaker_state",
+		map[string]string{"output": name},
+	))
 	ro.BufferLimit.Set(int64(ro.MetricBufferLimit))
 	return ro
 }
 
+// Connect connects the output, retrying as configured by the retry policy.
+// If every attempt fails the circuit breaker is opened and the output keeps
+// being reconnected in the background, while its metrics are buffered.
+func (ro *RunningOutput) Connect() error {
+	stop := ro.stopChan()
+	var err error
+	for attempt := 1; attempt <= ro.breaker.MaxAttempts(); attempt++ {
+		if err = ro.connect(); err == nil {
+			log.Printf("D! Successfully connected to output: %s\n", ro.Name)
+			return nil
+		}
+		if attempt == ro.breaker.MaxAttempts() {
+			break
+		}
+
+		backoff := ro.breaker.Backoff(attempt)
+		log.Printf("E! Failed to connect to output %s, retrying in %s, "+
+			"error was '%s' \n", ro.Name, backoff, err)
+		select {
+		case <-stop:
+			return err
+		case <-ro.breaker.Wait(backoff):
+		}
+	}
+
+	log.Printf("E! Failed to connect to output %s after %d attempts, "+
+		"reconnecting in the background, error was '%s' \n",
+		ro.Name, ro.breaker.MaxAttempts(), err)
+	if ro.breaker.Open() {
+		ro.startReconnect(stop)
+	}
+	return nil
+}
+
+// stopChan returns the channel closed by the next call to Close.
+func (ro *RunningOutput) stopChan() chan struct{} {
+	ro.Lock()
+	defer ro.Unlock()
+	return ro.stop
+}
+
+// connect (re)connects the output, closing it first if it was connected.
+func (ro *RunningOutput) connect() error {
+	ro.Lock()
+	defer ro.Unlock()
+	if ro.connected {
+		if err := ro.Output.Close(); err != nil {
+			log.Printf("E! Error closing output %s before reconnecting: %s\n",
+				ro.Name, err)
+		}
+		ro.connected = false
+	}
+
+	log.Printf("D! Attempting connection to output: %s\n", ro.Name)
+	if err := ro.Output.Connect(); err != nil {
+		return err
+	}
+	ro.connected = true
+	return nil
+}
+
+func (ro *RunningOutput) startReconnect(stop chan struct{}) {
+	ro.wg.Add(1)
+	go ro.reconnect(stop)
+}
+
+// reconnect runs while the circuit breaker is open, trying to reconnect the
+// output with an exponential backoff. Once connected the breaker is
+// half-open, and the next write decides whether it closes again.
+func (ro *RunningOutput) reconnect(stop chan struct{}) {
+	defer ro.wg.Done()
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-stop:
+			return
+		case <-ro.breaker.Wait(ro.breaker.Backoff(attempt)):
+		}
+
+		if err := ro.connect(); err != nil {
+			log.Printf("E! Failed to reconnect to output %s, error was '%s' \n",
+				ro.Name, err)
+			continue
+		}
+		log.Printf("I! Reconnected to output %s\n", ro.Name)
+		ro.breaker.HalfOpen()
+		return
+	}
+}
+
 // AddMetric adds a metric to the output. This function can also write cached
 // points if FlushBufferWhenFull is true.
 func (ro *RunningOutput) AddMetric(m telegraf.Metric) {
@@ -113,6 +223,14 @@ func (ro *RunningOutput) AddMetric(m telegraf.Metric) {
 		m, _ = metric.New(name, tags, fields, t)
 	}
 
+	if ro.diskBuffer != nil {
+		if err := ro.diskBuffer.Add(m); err != nil {
+			log.Printf("E! Output [%s] could not add metric to disk buffer: %s\n",
+				ro.Name, err)
+		}
+		return
+	}
+
 	ro.metrics.Add(m)
 	if ro.metrics.Len() == ro.MetricBatchSize {
 		batch := ro.metrics.Batch(ro.MetricBatchSize)
@@ -123,8 +241,41 @@ func (ro *RunningOutput) AddMetric(m telegraf.Metric) {
 	}
 }
 
+// OpenDiskBuffer opens the write-ahead buffer configured by the
+// buffer_directory option, replacing the in-memory buffers. Metrics left in
+// the buffer by a previous run will be written on the next flush.
+func (ro *RunningOutput) OpenDiskBuffer() error {
+	db, err := buffer.NewDiskBuffer(ro.Config.BufferDirectory,
+		ro.Config.BufferMaxBytes, ro.Config.BufferFsync)
+	if err != nil {
+		return err
+	}
+	ro.diskBuffer = db
+	ro.BufferSize.Set(int64(db.Len()))
+	if !db.IsEmpty() {
+		log.Printf("I! Output [%s] recovered %d metrics from disk buffer %s\n",
+			ro.Name, db.Len(), ro.Config.BufferDirectory)
+	}
+	return nil
+}
+
 // Write writes all cached points to this output.
 func (ro *RunningOutput) Write() error {
+	if !ro.breaker.Allow() {
+		if state := ro.breaker.Current(); state == BreakerClosed {
+			log.Printf("D! Output [%s] is backing off after a failed write, "+
+				"deferring write\n", ro.Name)
+		} else {
+			log.Printf("D! Output [%s] circuit breaker is %s, deferring write\n",
+				ro.Name, state)
+		}
+		return nil
+	}
+
+	if ro.diskBuffer != nil {
+		return ro.writeDiskBuffer()
+	}
+
 	nFails, nMetrics := ro.failMetrics.Len(), ro.metrics.Len()
 	ro.BufferSize.Set(int64(nFails + nMetrics))
 	log.Printf("D! Output [%s] buffer fullness: %d / %d metrics. ",
@@ -163,32 +314,114 @@ func (ro *RunningOutput) Write() error {
 
 	if err != nil {
 		ro.failMetrics.Add(batch...)
+		ro.BufferSize.Set(int64(ro.failMetrics.Len() + ro.metrics.Len()))
 		return err
 	}
+	ro.BufferSize.Set(int64(ro.failMetrics.Len() + ro.metrics.Len()))
 	return nil
 }
 
+// writeDiskBuffer writes the metrics stored in the disk buffer, oldest first.
+// Each batch is only removed from the buffer once it has been written, so
+// writing stops at the first error and resumes from the same batch on the
+// next flush.
+func (ro *RunningOutput) writeDiskBuffer() error {
+	if err := ro.diskBuffer.Sync(); err != nil {
+		log.Printf("E! Output [%s] could not sync disk buffer: %s\n", ro.Name, err)
+	}
+
+	nMetrics := ro.diskBuffer.Len()
+	ro.BufferSize.Set(int64(nMetrics))
+	log.Printf("D! Output [%s] buffer fullness: %d metrics on disk. ",
+		ro.Name, nMetrics)
+
+	// Only write the metrics buffered before this flush started, otherwise a
+	// steady stream of new metrics could keep the flush going forever.
+	var err error
+	for ; nMetrics > 0; nMetrics -= ro.MetricBatchSize {
+		var batch []telegraf.Metric
+		batch, err = ro.diskBuffer.Peek(ro.MetricBatchSize)
+		if err != nil {
+			break
+		}
+		if err = ro.write(batch); err != nil {
+			break
+		}
+		if err = ro.diskBuffer.Ack(); err != nil {
+			break
+		}
+	}
+
+	ro.BufferSize.Set(int64(ro.diskBuffer.Len()))
+	return err
+}
+
+// Close stops reconnecting the output, and closes the output and its disk
+// buffer, if any. A closed output can be connected again.
+func (ro *RunningOutput) Close() error {
+	ro.Lock()
+	close(ro.stop)
+	ro.stop = make(chan struct{})
+	ro.Unlock()
+	ro.wg.Wait()
+
+	var err error
+	ro.Lock()
+	if ro.connected {
+		err = ro.Output.Close()
+		ro.connected = false
+	}
+	ro.Unlock()
+	if ro.diskBuffer != nil {
+		if berr := ro.diskBuffer.Close(); err == nil {
+			err = berr
+		}
+	}
+	return err
+}
+
 func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
 	nMetrics := len(metrics)
 	if nMetrics == 0 {
 		return nil
 	}
+	if !ro.breaker.Allow() {
+		return errBackoff
+	}
 	ro.Lock()
 	defer ro.Unlock()
 	start := time.Now()
 	err := ro.Output.Write(metrics)
 	elapsed := time.Since(start)
-	if err == nil {
-		log.Printf("D! Output [%s] wrote batch of %d metrics in %s\n",
-			ro.Name, nMetrics, elapsed)
-		ro.MetricsWritten.Incr(int64(nMetrics))
-		ro.WriteTime.Incr(elapsed.Nanoseconds())
+	if err != nil {
+		if ro.breaker.Failure() {
+			log.Printf("E! Output [%s] circuit breaker opened, reconnecting\n",
+				ro.Name)
+			ro.startReconnect(ro.stop)
+		}
+		return err
 	}
-	return err
+
+	ro.breaker.Success()
+	log.Printf("D! Output [%s] wrote batch of %d metrics in %s\n",
+		ro.Name, nMetrics, elapsed)
+	ro.MetricsWritten.Incr(int64(nMetrics))
+	ro.WriteTime.Incr(elapsed.Nanoseconds())
+	return nil
 }
 
 // OutputConfig containing name and filter
 type OutputConfig struct {
 	Name   string
 	Filter Filter
+
+	// BufferDirectory enables the disk buffer when set.
+	BufferDirectory string
+	BufferMaxBytes  int64
+	BufferFsync     string
+
+	Retry RetryConfig
+
+	// Signature identifies the configuration the output was built from.
+	Signature string
 }
@@ -2,8 +2,12 @@ package models
 
 import (
 	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
@@ -28,6 +32,13 @@ var next5 = []telegraf.Metric{
 	testutil.TestMetric(101, "metric10"),
 }
 
+// retryEveryWrite never delays a write nor opens the circuit breaker, so that
+// every call to Write is attempted.
+var retryEveryWrite = RetryConfig{
+	InitialBackoff: time.Nanosecond,
+	MaxAttempts:    math.MaxInt32,
+}
+
 // Benchmark adding metrics.
 func BenchmarkRunningOutputAddWrite(b *testing.B) {
 	conf := &OutputConfig{
@@ -64,6 +75,7 @@ func BenchmarkRunningOutputAddWriteEvery100(b *testing.B) {
 func BenchmarkRunningOutputAddFailWrites(b *testing.B) {
 	conf := &OutputConfig{
 		Filter: Filter{},
+		Retry:  retryEveryWrite,
 	}
 
 	m := &perfOutput{}
@@ -301,6 +313,7 @@ func TestRunningOutputMultiFlushWhenFull(t *testing.T) {
 func TestRunningOutputWriteFail(t *testing.T) {
 	conf := &OutputConfig{
 		Filter: Filter{},
+		Retry:  retryEveryWrite,
 	}
 
 	m := &mockOutput{}
@@ -334,6 +347,7 @@ func TestRunningOutputWriteFail(t *testing.T) {
 func TestRunningOutputWriteFailOrder(t *testing.T) {
 	conf := &OutputConfig{
 		Filter: Filter{},
+		Retry:  retryEveryWrite,
 	}
 
 	m := &mockOutput{}
@@ -372,6 +386,7 @@ func TestRunningOutputWriteFailOrder(t *testing.T) {
 func TestRunningOutputWriteFailOrder2(t *testing.T) {
 	conf := &OutputConfig{
 		Filter: Filter{},
+		Retry:  retryEveryWrite,
 	}
 
 	m := &mockOutput{}
@@ -443,6 +458,7 @@ func TestRunningOutputWriteFailOrder2(t *testing.T) {
 func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	conf := &OutputConfig{
 		Filter: Filter{},
+		Retry:  retryEveryWrite,
 	}
 
 	m := &mockOutput{}
@@ -479,6 +495,131 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	assert.Equal(t, expected, m.Metrics())
 }
 
+// Verify that the disk buffer keeps metrics across failed writes and
+// restarts, and writes them in order.
+func TestRunningOutputDiskBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := &OutputConfig{
+		Filter:          Filter{},
+		BufferDirectory: dir,
+		BufferFsync:     "always",
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 4, 12)
+	require.NoError(t, ro.OpenDiskBuffer())
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+	assert.Len(t, m.Metrics(), 0)
+	assert.Equal(t, int64(5), ro.BufferSize.Get())
+	require.NoError(t, ro.Close())
+
+	ro = NewRunningOutput("test", m, conf, 4, 12)
+	require.NoError(t, ro.OpenDiskBuffer())
+	defer ro.Close()
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	assert.Equal(t, int64(0), ro.BufferSize.Get())
+
+	received := m.Metrics()
+	require.Len(t, received, 10)
+	for i, metric := range append(first5, next5...) {
+		assert.Equal(t, metric.Name(), received[i].Name())
+	}
+}
+
+func TestRunningOutputCircuitBreaker(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+		Retry: RetryConfig{
+			InitialBackoff: time.Second,
+			MaxBackoff:     time.Second,
+			MaxAttempts:    2,
+		},
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 10, 20)
+	clock := newMockClock(ro.breaker)
+	require.NoError(t, ro.Connect())
+	defer ro.Close()
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	// the first failure only delays the next write
+	require.Error(t, ro.Write())
+	assert.Equal(t, BreakerClosed, ro.breaker.Current())
+	assert.NoError(t, ro.Write())
+	assert.Equal(t, 1, m.Connects())
+
+	clock.Add(time.Second)
+	m.Lock()
+	m.failConnect = true
+	m.Unlock()
+	require.Error(t, ro.Write())
+
+	// the second failure opens the breaker until the output is reconnected
+	assert.Equal(t, BreakerOpen, ro.breaker.Current())
+	assert.Equal(t, int64(BreakerOpen), ro.breaker.State.Get())
+	assert.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 0)
+
+	// a failed reconnect is retried after the next backoff
+	clock.Fire()
+	timer := clock.Next()
+	assert.Equal(t, 2, m.Connects())
+	assert.Equal(t, BreakerOpen, ro.breaker.Current())
+
+	m.Lock()
+	m.failWrite = false
+	m.failConnect = false
+	m.Unlock()
+	timer <- clock.Now()
+	ro.wg.Wait()
+	assert.Equal(t, 3, m.Connects())
+	assert.Equal(t, BreakerHalfOpen, ro.breaker.Current())
+
+	require.NoError(t, ro.Write())
+	assert.Equal(t, BreakerClosed, ro.breaker.Current())
+	assert.Len(t, m.Metrics(), 5)
+}
+
+func TestRunningOutputConnectFail(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+		Retry: RetryConfig{
+			InitialBackoff: time.Second,
+			MaxAttempts:    2,
+		},
+	}
+
+	m := &mockOutput{}
+	m.failConnect = true
+	ro := NewRunningOutput("test", m, conf, 4, 12)
+	clock := newMockClock(ro.breaker)
+
+	// the output is reconnected in the background
+	go clock.Fire()
+	require.NoError(t, ro.Connect())
+	assert.Equal(t, 2, m.Connects())
+	assert.Equal(t, BreakerOpen, ro.breaker.Current())
+	require.NoError(t, ro.Close())
+}
+
 type mockOutput struct {
 	sync.Mutex
 
@@ -486,12 +627,28 @@ type mockOutput struct {
 
 	// if true, mock a write failure
 	failWrite bool
+
+	// if true, mock a connection failure
+	failConnect bool
+	connects    int
 }
 
 func (m *mockOutput) Connect() error {
+	m.Lock()
+	defer m.Unlock()
+	m.connects++
+	if m.failConnect {
+		return fmt.Errorf("Failed Connect!")
+	}
 	return nil
 }
 
+func (m *mockOutput) Connects() int {
+	m.Lock()
+	defer m.Unlock()
+	return m.connects
+}
+
 func (m *mockOutput) Close() error {
 	return nil
 }
@@ -114,6 +114,7 @@ func TestAddFieldOverwrites(t *testing.T) {
 	value, ok := m.GetField("value")
 	require.True(t, ok)
 	require.Equal(t, 42.0, value)
+	require.Len(t, m.FieldList(), 1)
 }
 
 func TestAddFieldChangesType(t *testing.T) {
@@ -3,6 +3,7 @@ package basicstats
 import (
 	"log"
 	"math"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/aggregators"
@@ -16,13 +17,17 @@ type BasicStats struct {
 }
 
 type configuredStats struct {
-	count    bool
-	min      bool
-	max      bool
-	mean     bool
-	variance bool
-	stdev    bool
-	sum      bool
+	count           bool
+	min             bool
+	max             bool
+	mean            bool
+	variance        bool
+	stdev           bool
+	sum             bool
+	diff            bool
+	nonNegativeDiff bool
+	rate            bool
+	interval        bool
 }
 
 func NewBasicStats() *BasicStats {
@@ -44,6 +49,26 @@ type basicstats struct {
 	sum   float64
 	mean  float64
 	M2    float64 //intermedia value for variance/stdev
+	//first and last value and their time, for diff/rate/interval
+	first     float64
+	last      float64
+	firstTime time.Time
+	lastTime  time.Time
+}
+
+func newBasicstats(fv float64, t time.Time) basicstats {
+	return basicstats{
+		count:     1,
+		min:       fv,
+		max:       fv,
+		mean:      fv,
+		sum:       fv,
+		M2:        0.0,
+		first:     fv,
+		last:      fv,
+		firstTime: t,
+		lastTime:  t,
+	}
 }
 
 var sampleConfig = `
@@ -53,6 +78,9 @@ var sampleConfig = `
   ## If true, the original metric will be dropped by the
   ## aggregator and will not get sent to the output plugins.
   drop_original = false
+
+  ## Configures which basic stats to push as fields
+  # stats = ["count", "min", "max", "mean", "stdev", "s2", "sum"]
 `
 
 func (m *BasicStats) SampleConfig() string {
@@ -74,14 +102,7 @@ func (m *BasicStats) Add(in telegraf.Metric) {
 		}
 		for k, v := range in.Fields() {
 			if fv, ok := convert(v); ok {
-				a.fields[k] = basicstats{
-					count: 1,
-					min:   fv,
-					max:   fv,
-					mean:  fv,
-					sum:   fv,
-					M2:    0.0,
-				}
+				a.fields[k] = newBasicstats(fv, in.Time())
 			}
 		}
 		m.cache[id] = a
@@ -90,14 +111,7 @@ func (m *BasicStats) Add(in telegraf.Metric) {
 			if fv, ok := convert(v); ok {
 				if _, ok := m.cache[id].fields[k]; !ok {
 					// hit an uncached field of a cached metric
-					m.cache[id].fields[k] = basicstats{
-						count: 1,
-						min:   fv,
-						max:   fv,
-						mean:  fv,
-						sum:   fv,
-						M2:    0.0,
-					}
+					m.cache[id].fields[k] = newBasicstats(fv, in.Time())
 					continue
 				}
 
@@ -125,6 +139,9 @@ func (m *BasicStats) Add(in telegraf.Metric) {
 				}
 				//sum compute
 				tmp.sum += fv
+				//last value for diff/rate/interval
+				tmp.last = fv
+				tmp.lastTime = in.Time()
 				//store final data
 				m.cache[id].fields[k] = tmp
 			}
@@ -156,6 +173,22 @@ func (m *BasicStats) Push(acc telegraf.Accumulator) {
 				fields[k+"_sum"] = v.sum
 			}
 
+			diff := v.last - v.first
+			interval := v.lastTime.Sub(v.firstTime)
+			if config.diff {
+				fields[k+"_diff"] = diff
+			}
+			if config.nonNegativeDiff && diff >= 0 {
+				fields[k+"_non_negative_diff"] = diff
+			}
+			if config.interval {
+				fields[k+"_interval"] = interval.Nanoseconds()
+			}
+			//rate is undefined without elapsed time
+			if config.rate && interval > 0 {
+				fields[k+"_rate"] = diff / interval.Seconds()
+			}
+
 			//v.count always >=1
 			if v.count > 1 {
 				variance := v.M2 / (v.count - 1)
@@ -198,6 +231,14 @@ func parseStats(names []string) *configuredStats {
 			parsed.stdev = true
 		case "sum":
 			parsed.sum = true
+		case "diff":
+			parsed.diff = true
+		case "non_negative_diff":
+			parsed.nonNegativeDiff = true
+		case "rate":
+			parsed.rate = true
+		case "interval":
+			parsed.interval = true
 
 		default:
 			log.Printf("W! Unrecognized basic stat '%s', ignoring", name)
@@ -218,6 +259,10 @@ func defaultStats() *configuredStats {
 	defaults.variance = true
 	defaults.stdev = true
 	defaults.sum = false
+	defaults.diff = false
+	defaults.nonNegativeDiff = false
+	defaults.rate = false
+	defaults.interval = false
 
 	return defaults
 }
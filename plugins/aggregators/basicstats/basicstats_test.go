@@ -509,3 +509,63 @@ func TestBasicStatsWithDefaultStats(t *testing.T) {
 	assert.True(t, acc.HasField("m1", "a_s2"))
 	assert.False(t, acc.HasField("m1", "a_sum"))
 }
+
+// Test that diff, non_negative_diff, rate and interval are computed from the
+// first and last values of the period.
+func TestBasicStatsWithDiffRateInterval(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	first, _ := metric.New("m1",
+		map[string]string{"foo": "bar"},
+		map[string]interface{}{"a": int64(10), "b": float64(8)},
+		start,
+	)
+	second, _ := metric.New("m1",
+		map[string]string{"foo": "bar"},
+		map[string]interface{}{"a": int64(40), "b": float64(9)},
+		start.Add(5*time.Second),
+	)
+	last, _ := metric.New("m1",
+		map[string]string{"foo": "bar"},
+		map[string]interface{}{"a": int64(30), "b": float64(2)},
+		start.Add(10*time.Second),
+	)
+
+	aggregator := NewBasicStats()
+	aggregator.Stats = []string{"diff", "non_negative_diff", "rate", "interval"}
+
+	aggregator.Add(first)
+	aggregator.Add(second)
+	aggregator.Add(last)
+
+	acc := testutil.Accumulator{}
+	aggregator.Push(&acc)
+
+	expectedFields := map[string]interface{}{
+		"a_diff":              float64(20),
+		"a_non_negative_diff": float64(20),
+		"a_rate":              float64(2),
+		"a_interval":          int64(10 * time.Second),
+		"b_diff":              float64(-6),
+		"b_rate":              float64(-0.6),
+		"b_interval":          int64(10 * time.Second),
+	}
+	expectedTags := map[string]string{
+		"foo": "bar",
+	}
+	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
+}
+
+// Test that no rate is computed from a single value.
+func TestBasicStatsWithRateSingleValue(t *testing.T) {
+	aggregator := NewBasicStats()
+	aggregator.Stats = []string{"diff", "rate", "interval"}
+
+	aggregator.Add(m2)
+
+	acc := testutil.Accumulator{}
+	aggregator.Push(&acc)
+
+	assert.True(t, acc.HasField("m1", "e_diff"))
+	assert.True(t, acc.HasField("m1", "e_interval"))
+	assert.False(t, acc.HasField("m1", "e_rate"))
+}
@@ -0,0 +1,93 @@
+package final
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## The time that a series is not updated until considering it final.  The
+  ## default of 0 emits the last values of each series at the end of every
+  ## period.
+  # series_timeout = "5m"
+`
+
+type Final struct {
+	SeriesTimeout internal.Duration `toml:"series_timeout"`
+
+	// cache holds the last values of the series not yet emitted, it is kept
+	// across periods until the series times out.
+	cache map[uint64]*aggregate
+}
+
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	time   time.Time
+}
+
+func NewFinal() telegraf.Aggregator {
+	return &Final{
+		cache: make(map[uint64]*aggregate),
+	}
+}
+
+func (f *Final) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *Final) Description() string {
+	return "Report the final metric of a series"
+}
+
+func (f *Final) Add(in telegraf.Metric) {
+	id := in.HashID()
+	a, ok := f.cache[id]
+	if !ok {
+		a = &aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]interface{}),
+		}
+		f.cache[id] = a
+	}
+	for k, v := range in.Fields() {
+		a.fields[k] = v
+	}
+	a.time = in.Time()
+}
+
+func (f *Final) Push(acc telegraf.Accumulator) {
+	for id, a := range f.cache {
+		if time.Since(a.time) < f.SeriesTimeout.Duration {
+			continue
+		}
+		fields := make(map[string]interface{}, len(a.fields))
+		for k, v := range a.fields {
+			fields[k+"_final"] = v
+		}
+		acc.AddFields(a.name, fields, a.tags, a.time)
+		delete(f.cache, id)
+	}
+}
+
+// Reset keeps the cache, the series are removed from it once emitted.
+func (f *Final) Reset() {
+}
+
+func init() {
+	aggregators.Add("final", func() telegraf.Aggregator {
+		return NewFinal()
+	})
+}
@@ -0,0 +1,98 @@
+package final
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func mustMetric(
+	name string,
+	tags map[string]string,
+	fields map[string]interface{},
+	tm time.Time,
+) telegraf.Metric {
+	m, err := metric.New(name, tags, fields, tm)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestSimple(t *testing.T) {
+	acc := testutil.Accumulator{}
+	final := NewFinal()
+
+	tags := map[string]string{"foo": "bar"}
+	now := time.Now()
+	final.Add(mustMetric("m1", tags, map[string]interface{}{"a": int64(1)}, now.Add(-2*time.Second)))
+	final.Add(mustMetric("m1", tags, map[string]interface{}{"a": int64(2), "b": "x"}, now.Add(-time.Second)))
+	final.Add(mustMetric("m1", tags, map[string]interface{}{"a": int64(3)}, now))
+	final.Push(&acc)
+
+	require.Equal(t, 1, len(acc.Metrics))
+	acc.AssertContainsTaggedFields(t, "m1",
+		map[string]interface{}{"a_final": int64(3), "b_final": "x"},
+		tags)
+	require.Equal(t, now, acc.Metrics[0].Time)
+
+	// Series are not emitted again without new metrics.
+	final.Reset()
+	acc.ClearMetrics()
+	final.Push(&acc)
+	require.Equal(t, 0, len(acc.Metrics))
+}
+
+func TestTwoSeries(t *testing.T) {
+	acc := testutil.Accumulator{}
+	final := NewFinal()
+
+	now := time.Now()
+	final.Add(mustMetric("m1", map[string]string{"foo": "bar"}, map[string]interface{}{"a": 1.0}, now))
+	final.Add(mustMetric("m1", map[string]string{"foo": "baz"}, map[string]interface{}{"a": 2.0}, now))
+	final.Add(mustMetric("m1", map[string]string{"foo": "bar"}, map[string]interface{}{"a": 3.0}, now))
+	final.Push(&acc)
+
+	require.Equal(t, 2, len(acc.Metrics))
+	acc.AssertContainsTaggedFields(t, "m1",
+		map[string]interface{}{"a_final": 3.0},
+		map[string]string{"foo": "bar"})
+	acc.AssertContainsTaggedFields(t, "m1",
+		map[string]interface{}{"a_final": 2.0},
+		map[string]string{"foo": "baz"})
+}
+
+func TestSeriesTimeout(t *testing.T) {
+	acc := testutil.Accumulator{}
+	final := &Final{
+		SeriesTimeout: internal.Duration{Duration: time.Minute},
+		cache:         make(map[uint64]*aggregate),
+	}
+
+	now := time.Now()
+	final.Add(mustMetric("m1", map[string]string{"foo": "quiet"}, map[string]interface{}{"a": 1.0}, now.Add(-2*time.Minute)))
+	final.Add(mustMetric("m1", map[string]string{"foo": "active"}, map[string]interface{}{"a": 2.0}, now))
+	final.Push(&acc)
+	final.Reset()
+
+	require.Equal(t, 1, len(acc.Metrics))
+	acc.AssertContainsTaggedFields(t, "m1",
+		map[string]interface{}{"a_final": 1.0},
+		map[string]string{"foo": "quiet"})
+
+	// The active series is kept until it goes quiet.
+	acc.ClearMetrics()
+	final.Add(mustMetric("m1", map[string]string{"foo": "active"}, map[string]interface{}{"a": 3.0}, now.Add(-time.Minute-time.Second)))
+	final.Push(&acc)
+
+	require.Equal(t, 1, len(acc.Metrics))
+	acc.AssertContainsTaggedFields(t, "m1",
+		map[string]interface{}{"a_final": 3.0},
+		map[string]string{"foo": "active"})
+}
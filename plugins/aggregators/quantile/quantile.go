@@ -0,0 +1,185 @@
+package quantile
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+const (
+	defaultRelativeAccuracy = 0.01
+	defaultMaxBins          = 2048
+)
+
+var defaultQuantiles = []float64{0.5, 0.95, 0.99}
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Quantiles to output in the range [0,1].  Each quantile q of a field is
+  ## emitted as the field <field>_p<100*q>, for example "latency_p99".
+  # quantiles = [0.5, 0.95, 0.99]
+
+  ## Maximum relative error of the estimated quantiles, in the range (0,1).
+  ## For the default of 0.01, a quantile of 100 is estimated between 99 and
+  ## 101.  A lower value uses more memory.
+  # relative_accuracy = 0.01
+
+  ## Maximum number of buckets kept per field and sign of the values, each
+  ## bucket using 8 bytes.  When the values span more buckets than allowed,
+  ## the accuracy is lost for those of the smallest magnitude.
+  # max_bins = 2048
+`
+
+type Quantile struct {
+	Quantiles        []float64 `toml:"quantiles"`
+	RelativeAccuracy float64   `toml:"relative_accuracy"`
+	MaxBins          int       `toml:"max_bins"`
+
+	initialized bool
+	mapping     *mapping
+	// suffixes holds the field name suffix of each quantile
+	suffixes []string
+	cache    map[uint64]aggregate
+}
+
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]*sketch
+}
+
+func NewQuantile() telegraf.Aggregator {
+	q := &Quantile{
+		Quantiles:        defaultQuantiles,
+		RelativeAccuracy: defaultRelativeAccuracy,
+		MaxBins:          defaultMaxBins,
+	}
+	q.Reset()
+	return q
+}
+
+func (q *Quantile) SampleConfig() string {
+	return sampleConfig
+}
+
+func (q *Quantile) Description() string {
+	return "Keep the aggregate quantiles of each metric passing through."
+}
+
+func (q *Quantile) Add(in telegraf.Metric) {
+	// The configuration is only checked once; invalid settings are logged
+	// and replaced by the defaults.
+	if !q.initialized {
+		q.init()
+	}
+
+	id := in.HashID()
+	a, ok := q.cache[id]
+	if !ok {
+		a = aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]*sketch),
+		}
+		q.cache[id] = a
+	}
+
+	for k, v := range in.Fields() {
+		fv, ok := convert(v)
+		if !ok {
+			continue
+		}
+		s, ok := a.fields[k]
+		if !ok {
+			s = newSketch(q.mapping)
+			a.fields[k] = s
+		}
+		s.add(fv)
+	}
+}
+
+func (q *Quantile) Push(acc telegraf.Accumulator) {
+	for _, a := range q.cache {
+		fields := make(map[string]interface{})
+		for k, s := range a.fields {
+			for i, quantile := range q.Quantiles {
+				fields[k+"_"+q.suffixes[i]] = s.quantile(quantile)
+			}
+		}
+		if len(fields) > 0 {
+			acc.AddFields(a.name, fields, a.tags)
+		}
+	}
+}
+
+func (q *Quantile) Reset() {
+	q.cache = make(map[uint64]aggregate)
+}
+
+func (q *Quantile) init() {
+	if q.RelativeAccuracy <= 0 || q.RelativeAccuracy >= 1 {
+		log.Printf("E! [aggregators.quantile] Relative accuracy %v not in (0,1), using %v\n",
+			q.RelativeAccuracy, defaultRelativeAccuracy)
+		q.RelativeAccuracy = defaultRelativeAccuracy
+	}
+	if q.MaxBins <= 0 {
+		q.MaxBins = defaultMaxBins
+	}
+	q.mapping = newMapping(q.RelativeAccuracy, q.MaxBins)
+
+	quantiles := make([]float64, 0, len(q.Quantiles))
+	q.suffixes = make([]string, 0, len(q.Quantiles))
+	for _, quantile := range q.Quantiles {
+		if quantile < 0 || quantile > 1 || math.IsNaN(quantile) {
+			log.Printf("E! [aggregators.quantile] Quantile %v not in [0,1], ignoring\n", quantile)
+			continue
+		}
+		quantiles = append(quantiles, quantile)
+		q.suffixes = append(q.suffixes, quantileSuffix(quantile))
+	}
+	q.Quantiles = quantiles
+
+	q.initialized = true
+}
+
+// quantileSuffix returns the name of a quantile as a percentile, such as
+// "p50" for 0.5 and "p99_9" for 0.999.
+func quantileSuffix(q float64) string {
+	p := strconv.FormatFloat(q*100, 'g', 10, 64)
+	return fmt.Sprintf("p%s", strings.Replace(p, ".", "_", -1))
+}
+
+func convert(in interface{}) (float64, bool) {
+	var v float64
+	switch in := in.(type) {
+	case float64:
+		v = in
+	case int64:
+		v = float64(in)
+	case uint64:
+		v = float64(in)
+	default:
+		return 0, false
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v, true
+}
+
+func init() {
+	aggregators.Add("quantile", func() telegraf.Aggregator {
+		return NewQuantile()
+	})
+}
@@ -0,0 +1,94 @@
+package quantile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func mustMetric(
+	name string,
+	tags map[string]string,
+	fields map[string]interface{},
+) telegraf.Metric {
+	m, err := metric.New(name, tags, fields, time.Now())
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestQuantileDefaults(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := NewQuantile()
+
+	tags := map[string]string{"server": "example.org"}
+	for i := 1; i <= 100; i++ {
+		q.Add(mustMetric("http_response",
+			tags,
+			map[string]interface{}{
+				"response_time": float64(i) / 1000,
+				"http_code":     int64(200),
+				"result":        "success",
+			}))
+	}
+	q.Push(&acc)
+
+	require.Equal(t, 1, len(acc.Metrics))
+	fields := acc.Metrics[0].Fields
+	require.Equal(t, 6, len(fields))
+	require.InEpsilon(t, 0.050, fields["response_time_p50"], 0.01)
+	require.InEpsilon(t, 0.095, fields["response_time_p95"], 0.01)
+	require.InEpsilon(t, 0.099, fields["response_time_p99"], 0.01)
+	require.Equal(t, 200.0, fields["http_code_p50"])
+	require.Equal(t, tags, acc.Metrics[0].Tags)
+}
+
+func TestQuantileReset(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := NewQuantile()
+
+	q.Add(mustMetric("m1", map[string]string{}, map[string]interface{}{"a": int64(1)}))
+	q.Push(&acc)
+	require.Equal(t, 1, len(acc.Metrics))
+
+	q.Reset()
+	acc.ClearMetrics()
+	q.Push(&acc)
+	require.Equal(t, 0, len(acc.Metrics))
+}
+
+func TestQuantileConfig(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := &Quantile{
+		Quantiles:        []float64{0, 0.999, 1.5, 1},
+		RelativeAccuracy: 2,
+	}
+	q.Reset()
+
+	q.Add(mustMetric("m1", map[string]string{}, map[string]interface{}{"a": 1.0}))
+	q.Add(mustMetric("m1", map[string]string{}, map[string]interface{}{"a": 9.0}))
+	q.Push(&acc)
+
+	require.Equal(t, defaultRelativeAccuracy, q.RelativeAccuracy)
+	require.Equal(t, defaultMaxBins, q.MaxBins)
+	acc.AssertContainsFields(t, "m1", map[string]interface{}{
+		"a_p0":    1.0,
+		"a_p99_9": 1.0,
+		"a_p100":  9.0,
+	})
+}
+
+func TestQuantileSuffix(t *testing.T) {
+	require.Equal(t, "p50", quantileSuffix(0.5))
+	require.Equal(t, "p95", quantileSuffix(0.95))
+	require.Equal(t, "p99_9", quantileSuffix(0.999))
+	require.Equal(t, "p99_99", quantileSuffix(0.9999))
+	require.Equal(t, "p0", quantileSuffix(0))
+	require.Equal(t, "p100", quantileSuffix(1))
+}
@@ -0,0 +1,179 @@
+package quantile
+
+import (
+	"math"
+)
+
+// mapping maps the values to the indexes of the sketch buckets, following
+// DDSketch (https://arxiv.org/abs/1908.10693).  The bucket of index k holds
+// the values in (gamma^(k-1), gamma^k], so that any of them is within the
+// relative accuracy of the value returned for the bucket.
+type mapping struct {
+	relativeAccuracy float64
+	gamma            float64
+	logGamma         float64
+	maxBins          int
+}
+
+func newMapping(relativeAccuracy float64, maxBins int) *mapping {
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &mapping{
+		relativeAccuracy: relativeAccuracy,
+		gamma:            gamma,
+		logGamma:         math.Log(gamma),
+		maxBins:          maxBins,
+	}
+}
+
+func (m *mapping) index(v float64) int {
+	return int(math.Ceil(math.Log(v) / m.logGamma))
+}
+
+func (m *mapping) value(index int) float64 {
+	return 2 * math.Exp(float64(index)*m.logGamma) / (1 + m.gamma)
+}
+
+// store counts the values of each bucket.  When the buckets span more than
+// maxBins indexes, the lowest buckets are collapsed into one, which bounds the
+// memory but loses the accuracy for the smallest magnitudes.
+type store struct {
+	bins   []uint64
+	offset int
+	count  uint64
+}
+
+func (s *store) add(index int, count uint64, maxBins int) {
+	if len(s.bins) == 0 {
+		s.bins = make([]uint64, 1)
+		s.offset = index
+	}
+
+	minIndex, maxIndex := s.offset, s.offset+len(s.bins)-1
+	if index < minIndex {
+		minIndex = index
+	}
+	if index > maxIndex {
+		maxIndex = index
+	}
+	if maxIndex-minIndex+1 > maxBins {
+		minIndex = maxIndex - maxBins + 1
+	}
+	s.resize(minIndex, maxIndex)
+
+	if index < s.offset {
+		index = s.offset
+	}
+	s.bins[index-s.offset] += count
+	s.count += count
+}
+
+func (s *store) resize(minIndex, maxIndex int) {
+	if minIndex == s.offset && maxIndex == s.offset+len(s.bins)-1 {
+		return
+	}
+
+	bins := make([]uint64, maxIndex-minIndex+1)
+	for i, c := range s.bins {
+		index := s.offset + i
+		if index < minIndex {
+			index = minIndex
+		}
+		bins[index-minIndex] += c
+	}
+	s.bins = bins
+	s.offset = minIndex
+}
+
+// indexAtRank returns the index of the bucket holding the value of the given
+// rank, counted from 0 in increasing order.
+func (s *store) indexAtRank(rank uint64) int {
+	var n uint64
+	for i, c := range s.bins {
+		n += c
+		if n > rank {
+			return s.offset + i
+		}
+	}
+	return s.offset + len(s.bins) - 1
+}
+
+// sketch estimates the quantiles of a stream of values, with a relative error
+// bounded by the relative accuracy of its mapping.  The negative values are
+// kept by magnitude in a store of their own.
+type sketch struct {
+	mapping  *mapping
+	positive store
+	negative store
+	zeros    uint64
+	count    uint64
+	min      float64
+	max      float64
+}
+
+func newSketch(m *mapping) *sketch {
+	return &sketch{
+		mapping: m,
+		min:     math.Inf(1),
+		max:     math.Inf(-1),
+	}
+}
+
+func (s *sketch) add(v float64) {
+	switch {
+	case v > 0:
+		s.positive.add(s.mapping.index(v), 1, s.mapping.maxBins)
+	case v < 0:
+		s.negative.add(s.mapping.index(-v), 1, s.mapping.maxBins)
+	default:
+		s.zeros++
+	}
+	s.count++
+	s.min = math.Min(s.min, v)
+	s.max = math.Max(s.max, v)
+}
+
+// merge adds the values of another sketch with the same mapping.
+func (s *sketch) merge(o *sketch) {
+	for i, c := range o.positive.bins {
+		if c > 0 {
+			s.positive.add(o.positive.offset+i, c, s.mapping.maxBins)
+		}
+	}
+	for i, c := range o.negative.bins {
+		if c > 0 {
+			s.negative.add(o.negative.offset+i, c, s.mapping.maxBins)
+		}
+	}
+	s.zeros += o.zeros
+	s.count += o.count
+	s.min = math.Min(s.min, o.min)
+	s.max = math.Max(s.max, o.max)
+}
+
+// quantile returns the estimate of the q-quantile, for q between 0 and 1.
+func (s *sketch) quantile(q float64) float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+
+	rank := uint64(q * float64(s.count-1))
+	var v float64
+	switch {
+	// The exact extremes are known.
+	case rank == 0:
+		return s.min
+	case rank == s.count-1:
+		return s.max
+	case rank < s.negative.count:
+		index := s.negative.indexAtRank(s.negative.count - 1 - rank)
+		v = -s.mapping.value(index)
+	case rank < s.negative.count+s.zeros:
+		v = 0
+	default:
+		index := s.positive.indexAtRank(rank - s.negative.count - s.zeros)
+		v = s.mapping.value(index)
+	}
+
+	// The estimates are kept within the extremes.
+	return math.Max(s.min, math.Min(s.max, v))
+}
@@ -0,0 +1,99 @@
+package quantile
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// requireAccurate checks the estimated quantiles against the exact ones, for
+// the same definition of the rank.
+func requireAccurate(t *testing.T, s *sketch, values []float64) {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	for _, q := range []float64{0, 0.01, 0.25, 0.5, 0.75, 0.9, 0.95, 0.99, 0.999, 1} {
+		exact := sorted[int(q*float64(len(sorted)-1))]
+		estimate := s.quantile(q)
+		require.InDelta(t, exact, estimate, s.mapping.relativeAccuracy*math.Abs(exact)+1e-12,
+			"quantile %v", q)
+	}
+}
+
+func TestSketchAccuracy(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	distributions := map[string]func() float64{
+		"uniform":     func() float64 { return rnd.Float64() * 1000 },
+		"exponential": rnd.ExpFloat64,
+		"normal":      func() float64 { return rnd.NormFloat64() * 100 },
+		"integers":    func() float64 { return float64(rnd.Intn(10)) },
+	}
+
+	for name, next := range distributions {
+		t.Run(name, func(t *testing.T) {
+			s := newSketch(newMapping(0.01, 2048))
+			values := make([]float64, 10000)
+			for i := range values {
+				values[i] = next()
+				s.add(values[i])
+			}
+			requireAccurate(t, s, values)
+		})
+	}
+}
+
+func TestSketchEmpty(t *testing.T) {
+	s := newSketch(newMapping(0.01, 2048))
+	require.True(t, math.IsNaN(s.quantile(0.5)))
+}
+
+func TestSketchExtremes(t *testing.T) {
+	s := newSketch(newMapping(0.05, 2048))
+	for _, v := range []float64{-3.3, 0, 7.7} {
+		s.add(v)
+	}
+	require.Equal(t, -3.3, s.quantile(0))
+	require.Equal(t, 0.0, s.quantile(0.5))
+	require.Equal(t, 7.7, s.quantile(1))
+}
+
+func TestSketchMerge(t *testing.T) {
+	m := newMapping(0.01, 2048)
+	rnd := rand.New(rand.NewSource(1))
+
+	s1, s2 := newSketch(m), newSketch(m)
+	values := make([]float64, 2000)
+	for i := range values {
+		values[i] = rnd.NormFloat64() * 100
+		if i%2 == 0 {
+			s1.add(values[i])
+		} else {
+			s2.add(values[i])
+		}
+	}
+
+	s1.merge(s2)
+	require.Equal(t, uint64(len(values)), s1.count)
+	requireAccurate(t, s1, values)
+}
+
+func TestSketchMaxBins(t *testing.T) {
+	s := newSketch(newMapping(0.01, 100))
+	values := make([]float64, 0, 10000)
+	for i := 1; i <= 10000; i++ {
+		values = append(values, float64(i))
+		s.add(float64(i))
+	}
+	require.Equal(t, 100, len(s.positive.bins))
+
+	// The lowest values are collapsed, the highest quantiles are still
+	// accurate.
+	for _, q := range []float64{0.9, 0.99} {
+		exact := values[int(q*float64(len(values)-1))]
+		require.InDelta(t, exact, s.quantile(q), 0.01*exact)
+	}
+	require.Equal(t, uint64(10000), s.positive.count)
+}
@@ -0,0 +1,116 @@
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+const sampleConfig = `
+  ## Files to parse each interval.
+  ## These accept standard unix glob matching rules, but with the addition of
+  ## ** as a "super asterisk". ie:
+  ##   /var/log/**.log     -> recursively find all .log files in /var/log
+  ##   /var/log/*/*.log    -> find all .log files with a parent dir in /var/log
+  ##   /var/log/apache.log -> only read the apache log file
+  ##
+  ## See https://github.com/gobwas/glob for more examples
+  ##
+  files = ["/var/log/apache/access.log"]
+
+  ## The dataformat to be read from files
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+type File struct {
+	Files []string `toml:"files"`
+
+	parser parsers.Parser
+	// maps the configured file paths to their compiled globs
+	globs map[string]*globpath.GlobPath
+}
+
+func NewFile() *File {
+	return &File{
+		globs: make(map[string]*globpath.GlobPath),
+	}
+}
+
+func (f *File) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *File) Description() string {
+	return "Reload and gather from file[s] on telegraf's interval"
+}
+
+func (f *File) Gather(acc telegraf.Accumulator) error {
+	for _, filepath := range f.Files {
+		g, ok := f.globs[filepath]
+		if !ok {
+			var err error
+			if g, err = globpath.Compile(filepath); err != nil {
+				acc.AddError(fmt.Errorf("glob %s failed to compile, %s", filepath, err))
+				continue
+			}
+			f.globs[filepath] = g
+		}
+
+		for fileName, fileInfo := range g.Match() {
+			if fileInfo.IsDir() {
+				continue
+			}
+			metrics, err := f.readMetric(fileName)
+			if err != nil {
+				acc.AddError(err)
+				continue
+			}
+			for _, m := range metrics {
+				tags := m.Tags()
+				tags["file"] = fileName
+				switch m.Type() {
+				case telegraf.Counter:
+					acc.AddCounter(m.Name(), m.Fields(), tags, m.Time())
+				case telegraf.Gauge:
+					acc.AddGauge(m.Name(), m.Fields(), tags, m.Time())
+				case telegraf.Summary:
+					acc.AddSummary(m.Name(), m.Fields(), tags, m.Time())
+				case telegraf.Histogram:
+					acc.AddHistogram(m.Name(), m.Fields(), tags, m.Time())
+				default:
+					acc.AddFields(m.Name(), m.Fields(), tags, m.Time())
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (f *File) SetParser(p parsers.Parser) {
+	f.parser = p
+}
+
+func (f *File) readMetric(filename string) ([]telegraf.Metric, error) {
+	fileContents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("file %s could not be read, %s", filename, err)
+	}
+	metrics, err := f.parser.Parse(fileContents)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing file %s, %s", filename, err)
+	}
+	return metrics, nil
+}
+
+func init() {
+	inputs.Add("file", func() telegraf.Input {
+		return NewFile()
+	})
+}
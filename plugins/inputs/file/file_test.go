@@ -0,0 +1,120 @@
+package file
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestGatherInflux(t *testing.T) {
+	dir := getTestdataDir()
+	f := NewFile()
+	f.Files = []string{dir + "cpu.influx"}
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+	f.SetParser(parser)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, acc.GatherError(f.Gather))
+
+	require.Equal(t, 2, len(acc.Metrics))
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{"usage_idle": 98.5, "usage_user": 1.5},
+		map[string]string{"host": "server01", "file": dir + "cpu.influx"})
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{"usage_idle": 97.1, "usage_user": 2.9},
+		map[string]string{"host": "server02", "file": dir + "cpu.influx"})
+}
+
+func TestGatherGlob(t *testing.T) {
+	dir := getTestdataDir()
+	f := NewFile()
+	f.Files = []string{dir + "*.json"}
+	parser, err := parsers.NewJSONParser("status", nil, nil)
+	require.NoError(t, err)
+	f.SetParser(parser)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, acc.GatherError(f.Gather))
+
+	acc.AssertContainsTaggedFields(t, "status",
+		map[string]interface{}{"queue_pending": float64(12), "queue_failed": float64(1)},
+		map[string]string{"file": dir + "status.json"})
+
+	// The file is read again on each interval.
+	require.NoError(t, acc.GatherError(f.Gather))
+	require.Equal(t, 2, len(acc.Metrics))
+}
+
+// typeAccumulator records the value type of the metrics added to it.
+type typeAccumulator struct {
+	testutil.Accumulator
+	types map[string]telegraf.ValueType
+}
+
+func (a *typeAccumulator) AddCounter(measurement string, fields map[string]interface{},
+	tags map[string]string, t ...time.Time) {
+	a.types[measurement] = telegraf.Counter
+	a.Accumulator.AddCounter(measurement, fields, tags, t...)
+}
+
+func (a *typeAccumulator) AddGauge(measurement string, fields map[string]interface{},
+	tags map[string]string, t ...time.Time) {
+	a.types[measurement] = telegraf.Gauge
+	a.Accumulator.AddGauge(measurement, fields, tags, t...)
+}
+
+func TestGatherKeepsType(t *testing.T) {
+	dir := getTestdataDir()
+	f := NewFile()
+	f.Files = []string{dir + "metrics.prom"}
+	parser, err := parsers.NewPrometheusParser(nil)
+	require.NoError(t, err)
+	f.SetParser(parser)
+
+	acc := &typeAccumulator{types: make(map[string]telegraf.ValueType)}
+	require.NoError(t, f.Gather(acc))
+	require.Empty(t, acc.Errors)
+
+	require.Equal(t, map[string]telegraf.ValueType{
+		"http_requests_total": telegraf.Counter,
+		"queue_length":        telegraf.Gauge,
+	}, acc.types)
+}
+
+func TestGatherParseError(t *testing.T) {
+	dir := getTestdataDir()
+	f := NewFile()
+	f.Files = []string{dir + "status.json"}
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+	f.SetParser(parser)
+
+	acc := testutil.Accumulator{}
+	require.Error(t, acc.GatherError(f.Gather))
+	require.Equal(t, 0, len(acc.Metrics))
+}
+
+func TestGatherMissingFile(t *testing.T) {
+	f := NewFile()
+	f.Files = []string{"/non/existent/file"}
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+	f.SetParser(parser)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, acc.GatherError(f.Gather))
+	require.Equal(t, 0, len(acc.Metrics))
+}
+
+func getTestdataDir() string {
+	_, filename, _, _ := runtime.Caller(1)
+	return strings.Replace(filename, "file_test.go", "testdata/", 1)
+}
@@ -50,6 +50,8 @@ type HTTPListener struct {
 	BasicUsername string
 	BasicPassword string
 
+	SkipErrors bool
+
 	TimeFunc
 
 	mu sync.Mutex
@@ -74,6 +76,7 @@ type HTTPListener struct {
 	NotFoundsServed selfstat.Stat
 	BuffersCreated  selfstat.Stat
 	AuthFailures    selfstat.Stat
+	LinesRejected   selfstat.Stat
 }
 
 const sampleConfig = `
@@ -105,6 +108,11 @@ const sampleConfig = `
   ## You probably want to make sure you have TLS configured above for this.
   # basic_username = "foobar"
   # basic_password = "barfoo"
+
+  ## Skip the invalid lines of a write and accept the valid ones, instead of
+  ## rejecting the whole write.  A write with invalid lines always receives a
+  ## 400 response.
+  # skip_errors = false
 `
 
 func (h *HTTPListener) SampleConfig() string {
@@ -140,6 +148,7 @@ func (h *HTTPListener) Start(acc telegraf.Accumulator) error {
 	h.NotFoundsServed = selfstat.Register("http_listener", "not_founds_served", tags)
 	h.BuffersCreated = selfstat.Register("http_listener", "buffers_created", tags)
 	h.AuthFailures = selfstat.Register("http_listener", "auth_failures", tags)
+	h.LinesRejected = selfstat.Register("http_listener", "lines_rejected", tags)
 
 	if h.MaxBodySize == 0 {
 		h.MaxBodySize = DEFAULT_MAX_BODY_SIZE
@@ -183,6 +192,7 @@ func (h *HTTPListener) Start(acc telegraf.Accumulator) error {
 
 	h.handler = influx.NewMetricHandler()
 	h.parser = influx.NewParser(h.handler)
+	h.parser.SkipErrors = h.SkipErrors
 
 	h.wg.Add(1)
 	go func() {
@@ -268,6 +278,9 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 	buf := h.pool.get()
 	defer h.pool.put(buf)
 	bufStart := 0
+	// position in the body of the start of the buffer, so that the parse
+	// errors refer to the body and not to the buffer
+	var pos bodyPosition
 	for {
 		n, err := io.ReadFull(body, buf[bufStart:])
 		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
@@ -291,10 +304,12 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 			i := bytes.IndexByte(buf, '\n')
 			if i == -1 {
 				// still didn't find a newline, keep scanning
+				pos.advance(buf[:n])
 				continue
 			}
 			// rotate the bit remaining after the first newline to the front of the buffer
 			i++ // start copying after the newline
+			pos.advance(buf[:i])
 			bufStart = len(buf) - i
 			if bufStart > 0 {
 				copy(buf, buf[i:])
@@ -305,8 +320,8 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 
 		if err == io.ErrUnexpectedEOF {
 			// finished reading the request body
-			if err := h.parse(buf[:n+bufStart], now, precision); err != nil {
-				log.Println("E! " + err.Error())
+			if err := h.parse(buf[:n+bufStart], pos, now, precision); err != nil {
+				log.Printf("E! http_listener rejected write from %s: %s", req.RemoteAddr, err)
 				return400 = true
 			}
 			if return400 {
@@ -328,14 +343,16 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 			hangingBytes = true
 			return400 = true
 			bufStart = 0
+			pos.advance(buf)
 			continue
 		}
-		if err := h.parse(buf[:i+1], now, precision); err != nil {
-			log.Println("E! " + err.Error())
+		if err := h.parse(buf[:i+1], pos, now, precision); err != nil {
+			log.Printf("E! http_listener rejected write from %s: %s", req.RemoteAddr, err)
 			return400 = true
 		}
 		// rotate the bit remaining after the last newline to the front of the buffer
 		i++ // start copying after the newline
+		pos.advance(buf[:i])
 		bufStart = len(buf) - i
 		if bufStart > 0 {
 			copy(buf, buf[i:])
@@ -343,11 +360,42 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
-func (h *HTTPListener) parse(b []byte, t time.Time, precision string) error {
+// bodyPosition is a position in a request body.
+type bodyPosition struct {
+	offset int
+	lines  int
+}
+
+// advance moves the position past the bytes.
+func (p *bodyPosition) advance(b []byte) {
+	p.offset += len(b)
+	p.lines += bytes.Count(b, []byte("\n"))
+}
+
+// relocate makes the position of a parse error of a buffer starting at p
+// relative to the body.
+func (p bodyPosition) relocate(err *influx.ParseError) {
+	err.Offset += p.offset
+	err.LineNumber += p.lines
+}
+
+func (h *HTTPListener) parse(b []byte, pos bodyPosition, t time.Time, precision string) error {
 	h.handler.SetTimePrecision(getPrecisionMultiplier(precision))
 	h.handler.SetTimeFunc(func() time.Time { return t })
 	metrics, err := h.parser.Parse(b)
-	if err != nil {
+	switch err := err.(type) {
+	case nil:
+	case influx.ParseErrors:
+		// The valid lines are still accepted.
+		h.LinesRejected.Incr(int64(len(err)))
+		for _, e := range err {
+			pos.relocate(e)
+		}
+	case *influx.ParseError:
+		h.LinesRejected.Incr(1)
+		pos.relocate(err)
+		return err
+	default:
 		return err
 	}
 
@@ -14,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/require"
@@ -519,6 +520,48 @@ func TestWriteHTTPInvalid(t *testing.T) {
 	require.EqualValues(t, 400, resp.StatusCode)
 }
 
+func TestWriteHTTPSkipErrors(t *testing.T) {
+	listener := newTestHTTPListener()
+	listener.SkipErrors = true
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+	rejected := listener.LinesRejected.Get()
+
+	// post a message with an invalid line to the listener
+	msg := badMsg + testMsg
+	resp, err := http.Post(createURL(listener, "http", "/write", "db=mydb"), "", bytes.NewBuffer([]byte(msg)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 400, resp.StatusCode)
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "cpu_load_short",
+		map[string]interface{}{"value": float64(12)},
+		map[string]string{"host": "server01"},
+	)
+	require.Equal(t, rejected+1, listener.LinesRejected.Get())
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	listener := newTestHTTPListener()
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	// the error is in the second buffer of the body
+	var pos bodyPosition
+	pos.advance([]byte(testMsg + testMsg))
+	err := listener.parse([]byte(testMsg+badMsg), pos, time.Now(), "")
+	perr, ok := err.(*influx.ParseError)
+	require.True(t, ok)
+	require.Equal(t, 4, perr.LineNumber)
+	require.True(t, perr.Offset >= 3*len(testMsg))
+	require.True(t, perr.Offset < 3*len(testMsg)+len(badMsg))
+}
+
 func TestWriteHTTPEmpty(t *testing.T) {
 	listener := newTestHTTPListener()
 
@@ -43,6 +43,13 @@ type Ping struct {
 	// URLs to ping
 	Urls []string
 
+	// Method used to send the pings, "exec" runs the ping command and
+	// "native" sends the ICMP echo requests directly
+	Method string
+
+	// Ping IPv6 addresses, only used by the native method
+	IPv6 bool `toml:"ipv6"`
+
 	// host ping function
 	pingHost HostPinger
 }
@@ -52,11 +59,17 @@ func (_ *Ping) Description() string {
 }
 
 const sampleConfig = `
-  ## NOTE: this plugin forks the ping command. You may need to set capabilities
-  ## via setcap cap_net_raw+p /bin/ping
+  ## NOTE: the exec method forks the ping command. You may need to set
+  ## capabilities via setcap cap_net_raw+p /bin/ping
   #
   ## List of urls to ping
   urls = ["www.google.com"] # required
+  ## method used to send the pings:
+  ##   exec:   run the system ping command and parse its output
+  ##   native: send the ICMP echo requests from telegraf, which requires
+  ##           unprivileged ICMP sockets (see net.ipv4.ping_group_range on
+  ##           Linux) or the CAP_NET_RAW capability
+  # method = "exec"
   ## number of pings to send per collection (ping -c <COUNT>)
   # count = 1
   ## interval, in s, at which to ping. 0 == default (ping -i <PING_INTERVAL>)
@@ -68,6 +81,8 @@ const sampleConfig = `
   ## interface or source address to send ping from (ping -I <INTERFACE/SRC_ADDR>)
   ## on Darwin and Freebsd only source address possible: (ping -S <SRC_ADDR>)
   # interface = ""
+  ## ping the IPv6 address of the urls instead of the IPv4 one, native only
+  # ipv6 = false
 `
 
 func (_ *Ping) SampleConfig() string {
@@ -75,6 +90,13 @@ func (_ *Ping) SampleConfig() string {
 }
 
 func (p *Ping) Gather(acc telegraf.Accumulator) error {
+	switch p.Method {
+	case "", "exec":
+	case "native":
+		return p.gatherNative(acc)
+	default:
+		return fmt.Errorf("invalid method %q, must be \"exec\" or \"native\"", p.Method)
+	}
 
 	var wg sync.WaitGroup
 
@@ -275,6 +297,7 @@ func init() {
 			Count:        1,
 			Timeout:      1.0,
 			Deadline:     10,
+			Method:       "exec",
 		}
 	})
 }
@@ -0,0 +1,453 @@
+// +build !windows
+
+package ping
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	protocolICMP     = 1
+	protocolIPv6ICMP = 58
+
+	// How long to wait for the replies when neither a timeout nor a deadline
+	// is configured.
+	defaultNativeTimeout = 10 * time.Second
+)
+
+// gatherNative pings all urls concurrently, sending the ICMP echo requests
+// directly instead of running the ping command.
+func (p *Ping) gatherNative(acc telegraf.Accumulator) error {
+	pinger := newNativePinger(p.Interface)
+	defer pinger.close()
+
+	var wg sync.WaitGroup
+	for _, url := range p.Urls {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			tags := map[string]string{"url": u}
+			fields := map[string]interface{}{"result_code": 0}
+
+			ip, err := p.resolve(u)
+			if err != nil {
+				acc.AddError(err)
+				fields["result_code"] = 1
+				acc.AddFields("ping", fields, tags)
+				return
+			}
+
+			rtts, trans, err := p.pingNative(pinger, ip)
+			if err != nil {
+				acc.AddError(fmt.Errorf("host %s: %s", u, err))
+				acc.AddFields("ping", fields, tags)
+				return
+			}
+
+			stats := computeStats(rtts)
+			fields["packets_transmitted"] = trans
+			fields["packets_received"] = len(rtts)
+			fields["percent_packet_loss"] = float64(trans-len(rtts)) / float64(trans) * 100.0
+			if len(rtts) > 0 {
+				fields["minimum_response_ms"] = stats.min
+				fields["average_response_ms"] = stats.avg
+				fields["maximum_response_ms"] = stats.max
+				fields["standard_deviation_ms"] = stats.stddev
+			}
+			if len(rtts) > 1 {
+				fields["jitter_ms"] = stats.jitter
+			}
+			acc.AddFields("ping", fields, tags)
+		}(url)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// resolve returns the address to ping for host, an IPv4 address unless ipv6
+// is set.
+func (p *Ping) resolve(host string) (net.IP, error) {
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if (ip.To4() == nil) == p.IPv6 {
+			return ip, nil
+		}
+	}
+	if p.IPv6 {
+		return nil, fmt.Errorf("no IPv6 address found for host %s", host)
+	}
+	return nil, fmt.Errorf("no IPv4 address found for host %s", host)
+}
+
+// pingNative sends count echo requests to ip, ping_interval apart, and
+// returns the round-trip time of each reply received, in milliseconds, along
+// with the number of requests sent.
+func (p *Ping) pingNative(pinger *nativePinger, ip net.IP) ([]float64, int, error) {
+	conn, err := pinger.conn(ip.To4() == nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var deadline <-chan time.Time
+	if p.Deadline > 0 {
+		timer := time.NewTimer(time.Duration(p.Deadline) * time.Second)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	timeout := time.Duration(p.Timeout * float64(time.Second))
+	if timeout <= 0 {
+		timeout = defaultNativeTimeout
+	}
+	interval := time.Duration(p.PingInterval * float64(time.Second))
+
+	var probes []*probe
+	defer func() {
+		for _, pr := range probes {
+			pinger.unregister(pr)
+		}
+	}()
+
+	count := p.Count
+	if count <= 0 {
+		count = 1
+	}
+	expired := false
+	for i := 0; i < count && !expired; i++ {
+		if i > 0 && interval > 0 {
+			select {
+			case <-time.After(interval):
+			case <-deadline:
+				expired = true
+				continue
+			}
+		}
+
+		pr := pinger.register(ip)
+		probes = append(probes, pr)
+		if err := conn.send(ip, pinger.id, pr.seq); err != nil {
+			return nil, len(probes), err
+		}
+	}
+
+	// Each request is given timeout to be answered, counted from the time it
+	// was sent.
+	rtts := make([]float64, 0, len(probes))
+	for _, pr := range probes {
+		wait := time.NewTimer(pr.sent.Add(timeout).Sub(time.Now()))
+		select {
+		case recv := <-pr.reply:
+			rtts = append(rtts, float64(recv.Sub(pr.sent))/float64(time.Millisecond))
+		case <-wait.C:
+		case <-deadline:
+			expired = true
+		}
+		wait.Stop()
+		if expired {
+			break
+		}
+	}
+	return rtts, len(probes), nil
+}
+
+// probe is an echo request waiting for its reply.
+type probe struct {
+	seq   int
+	ip    net.IP
+	sent  time.Time
+	reply chan time.Time
+}
+
+// nativePinger sends the echo requests of all targets over a single socket
+// per address family, and matches the replies to the requests by their
+// sequence number.
+type nativePinger struct {
+	id    int
+	iface string
+
+	mu      sync.Mutex
+	seq     int
+	pending map[int]*probe
+	conns   map[bool]*icmpConn
+	errs    map[bool]error
+	wg      sync.WaitGroup
+}
+
+func newNativePinger(iface string) *nativePinger {
+	return &nativePinger{
+		id:      (os.Getpid() ^ rand.Int()) & 0xffff,
+		iface:   iface,
+		seq:     rand.Intn(0xffff),
+		pending: make(map[int]*probe),
+		conns:   make(map[bool]*icmpConn),
+		errs:    make(map[bool]error),
+	}
+}
+
+// conn returns the socket of the IPv4 or IPv6 family, opening it on first
+// use.
+func (np *nativePinger) conn(v6 bool) (*icmpConn, error) {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+	if c, ok := np.conns[v6]; ok {
+		return c, nil
+	}
+	if err, ok := np.errs[v6]; ok {
+		return nil, err
+	}
+
+	c, err := listenICMP(v6, np.iface)
+	if err != nil {
+		np.errs[v6] = err
+		return nil, err
+	}
+	np.conns[v6] = c
+	np.wg.Add(1)
+	go np.receive(c)
+	return c, nil
+}
+
+func (np *nativePinger) register(ip net.IP) *probe {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+	// Skip the sequence numbers still waiting for a reply, in case of a wrap
+	// around with many targets.
+	for {
+		np.seq = (np.seq + 1) & 0xffff
+		if _, ok := np.pending[np.seq]; !ok {
+			break
+		}
+	}
+	pr := &probe{
+		seq:   np.seq,
+		ip:    ip,
+		sent:  time.Now(),
+		reply: make(chan time.Time, 1),
+	}
+	np.pending[pr.seq] = pr
+	return pr
+}
+
+func (np *nativePinger) unregister(pr *probe) {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+	if np.pending[pr.seq] == pr {
+		delete(np.pending, pr.seq)
+	}
+}
+
+// receive reads the replies from c until it is closed.
+func (np *nativePinger) receive(c *icmpConn) {
+	defer np.wg.Done()
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := c.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		np.handleReply(c, buf[:n], peer, time.Now())
+	}
+}
+
+// handleReply delivers an echo reply to the request it answers.
+func (np *nativePinger) handleReply(c *icmpConn, b []byte, peer net.Addr, recv time.Time) {
+	msg, err := icmp.ParseMessage(c.proto, b)
+	if err != nil || msg.Type != c.replyType {
+		return
+	}
+	echo, ok := msg.Body.(*icmp.Echo)
+	if !ok {
+		return
+	}
+	// Unprivileged sockets only receive their own replies, with the id
+	// rewritten by the kernel, while raw sockets receive all of them.
+	if c.raw && echo.ID != np.id {
+		return
+	}
+
+	np.mu.Lock()
+	pr, ok := np.pending[echo.Seq]
+	if ok && pr.ip.Equal(addrIP(peer)) {
+		delete(np.pending, echo.Seq)
+	} else {
+		ok = false
+	}
+	np.mu.Unlock()
+
+	if ok {
+		pr.reply <- recv
+	}
+}
+
+func (np *nativePinger) close() {
+	np.mu.Lock()
+	for _, c := range np.conns {
+		c.conn.Close()
+	}
+	np.mu.Unlock()
+	np.wg.Wait()
+}
+
+// icmpConn is an ICMP socket, either an unprivileged datagram socket or a raw
+// socket requiring the CAP_NET_RAW capability.
+type icmpConn struct {
+	conn      *icmp.PacketConn
+	raw       bool
+	proto     int
+	echoType  icmp.Type
+	replyType icmp.Type
+}
+
+// listenICMP opens an unprivileged ICMP socket, falling back to a raw socket
+// if the user is not allowed to (see net.ipv4.ping_group_range on Linux).
+func listenICMP(v6 bool, iface string) (*icmpConn, error) {
+	source, err := sourceAddress(v6, iface)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &icmpConn{
+		proto:     protocolICMP,
+		echoType:  ipv4.ICMPTypeEcho,
+		replyType: ipv4.ICMPTypeEchoReply,
+	}
+	network, rawNetwork := "udp4", "ip4:icmp"
+	if v6 {
+		c.proto = protocolIPv6ICMP
+		c.echoType = ipv6.ICMPTypeEchoRequest
+		c.replyType = ipv6.ICMPTypeEchoReply
+		network, rawNetwork = "udp6", "ip6:ipv6-icmp"
+	}
+
+	c.conn, err = icmp.ListenPacket(network, source)
+	if err == nil {
+		return c, nil
+	}
+	c.conn, err = icmp.ListenPacket(rawNetwork, source)
+	if err != nil {
+		return nil, fmt.Errorf("could not open ICMP socket, "+
+			"unprivileged ICMP or the CAP_NET_RAW capability is required: %s", err)
+	}
+	c.raw = true
+	return c, nil
+}
+
+// sourceAddress returns the address to send from, given the interface
+// option which is either an address or an interface name.
+func sourceAddress(v6 bool, iface string) (string, error) {
+	if iface == "" {
+		if v6 {
+			return "::", nil
+		}
+		return "0.0.0.0", nil
+	}
+	if ip := net.ParseIP(iface); ip != nil {
+		return ip.String(), nil
+	}
+
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return "", err
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && (ipnet.IP.To4() == nil) == v6 {
+			return ipnet.IP.String(), nil
+		}
+	}
+	return "", fmt.Errorf("no address of the right family on interface %s", iface)
+}
+
+func (c *icmpConn) send(ip net.IP, id, seq int) error {
+	msg := icmp.Message{
+		Type: c.echoType,
+		Code: 0,
+		Body: &icmp.Echo{
+			ID:   id,
+			Seq:  seq,
+			Data: []byte("telegraf ping"),
+		},
+	}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return err
+	}
+
+	var dst net.Addr = &net.UDPAddr{IP: ip}
+	if c.raw {
+		dst = &net.IPAddr{IP: ip}
+	}
+	n, err := c.conn.WriteTo(b, dst)
+	if err != nil {
+		return err
+	}
+	if n != len(b) {
+		return errors.New("short write of ICMP echo request")
+	}
+	return nil
+}
+
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	}
+	return nil
+}
+
+type rttStats struct {
+	min, avg, max, stddev, jitter float64
+}
+
+// computeStats returns the statistics of the round-trip times, in the order
+// the replies were received. The jitter is the mean difference between
+// consecutive round-trip times.
+func computeStats(rtts []float64) rttStats {
+	var stats rttStats
+	if len(rtts) == 0 {
+		return stats
+	}
+
+	stats.min, stats.max = rtts[0], rtts[0]
+	var sum, jitter float64
+	for i, rtt := range rtts {
+		sum += rtt
+		stats.min = math.Min(stats.min, rtt)
+		stats.max = math.Max(stats.max, rtt)
+		if i > 0 {
+			jitter += math.Abs(rtt - rtts[i-1])
+		}
+	}
+	stats.avg = sum / float64(len(rtts))
+
+	var variance float64
+	for _, rtt := range rtts {
+		variance += (rtt - stats.avg) * (rtt - stats.avg)
+	}
+	stats.stddev = math.Sqrt(variance / float64(len(rtts)))
+	if len(rtts) > 1 {
+		stats.jitter = jitter / float64(len(rtts)-1)
+	}
+	return stats
+}
@@ -0,0 +1,113 @@
+// +build !windows
+
+package ping
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeStats(t *testing.T) {
+	stats := computeStats([]float64{10, 20, 15, 35})
+	assert.Equal(t, 10.0, stats.min)
+	assert.Equal(t, 35.0, stats.max)
+	assert.Equal(t, 20.0, stats.avg)
+	assert.InDelta(t, 9.354, stats.stddev, 0.001)
+	assert.InDelta(t, 11.667, stats.jitter, 0.001)
+
+	assert.Equal(t, rttStats{}, computeStats(nil))
+}
+
+func echoReply(t *testing.T, id, seq int) []byte {
+	msg := icmp.Message{
+		Type: ipv4.ICMPTypeEchoReply,
+		Body: &icmp.Echo{ID: id, Seq: seq},
+	}
+	b, err := msg.Marshal(nil)
+	require.NoError(t, err)
+	return b
+}
+
+func TestNativePingerHandleReply(t *testing.T) {
+	np := newNativePinger("")
+	c := &icmpConn{
+		raw:       true,
+		proto:     protocolICMP,
+		echoType:  ipv4.ICMPTypeEcho,
+		replyType: ipv4.ICMPTypeEchoReply,
+	}
+	ip := net.ParseIP("192.0.2.1")
+	peer := &net.IPAddr{IP: ip}
+	pr := np.register(ip)
+	now := time.Now()
+
+	// replies to other processes, or from another host, are ignored
+	np.handleReply(c, echoReply(t, np.id+1, pr.seq), peer, now)
+	np.handleReply(c, echoReply(t, np.id, pr.seq),
+		&net.IPAddr{IP: net.ParseIP("192.0.2.2")}, now)
+	np.handleReply(c, echoReply(t, np.id, pr.seq+1), peer, now)
+	assert.Len(t, pr.reply, 0)
+
+	np.handleReply(c, echoReply(t, np.id, pr.seq), peer, now)
+	require.Len(t, pr.reply, 1)
+	assert.Equal(t, now, <-pr.reply)
+	assert.Len(t, np.pending, 0)
+
+	// duplicate replies are ignored
+	np.handleReply(c, echoReply(t, np.id, pr.seq), peer, now)
+	assert.Len(t, pr.reply, 0)
+}
+
+func TestNativePingGatherLocalhost(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping network-dependent test in short mode.")
+	}
+	if _, err := listenICMP(false, ""); err != nil {
+		t.Skipf("cannot open an ICMP socket: %s", err)
+	}
+
+	var acc testutil.Accumulator
+	p := Ping{
+		Urls:         []string{"127.0.0.1", "localhost"},
+		Method:       "native",
+		Count:        3,
+		PingInterval: 0.01,
+		Timeout:      1.0,
+	}
+	require.NoError(t, acc.GatherError(p.Gather))
+
+	for _, url := range p.Urls {
+		tags := map[string]string{"url": url}
+		assert.True(t, acc.HasPoint("ping", tags, "packets_transmitted", 3))
+		assert.True(t, acc.HasPoint("ping", tags, "packets_received", 3))
+		assert.True(t, acc.HasPoint("ping", tags, "percent_packet_loss", 0.0))
+		assert.True(t, acc.HasPoint("ping", tags, "result_code", 0))
+	}
+}
+
+func TestNativePingGatherBadHost(t *testing.T) {
+	var acc testutil.Accumulator
+	p := Ping{
+		Urls:   []string{"host.invalid"},
+		Method: "native",
+		Count:  1,
+	}
+	require.NoError(t, p.Gather(&acc))
+	assert.Len(t, acc.Errors, 1)
+	assert.True(t, acc.HasPoint("ping", map[string]string{"url": "host.invalid"},
+		"result_code", 1))
+}
+
+func TestInvalidMethod(t *testing.T) {
+	var acc testutil.Accumulator
+	p := Ping{Urls: []string{"localhost"}, Method: "carrier_pigeon"}
+	assert.Error(t, acc.GatherError(p.Gather))
+}
@@ -4,6 +4,7 @@ package ping
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"os/exec"
 	"regexp"
@@ -32,6 +33,12 @@ type Ping struct {
 	// URLs to ping
 	Urls []string
 
+	// Method used to send the pings, only "exec" is available on Windows
+	Method string
+
+	// Ping IPv6 addresses, only used by the native method
+	IPv6 bool `toml:"ipv6"`
+
 	// host ping function
 	pingHost HostPinger
 }
@@ -44,6 +51,9 @@ const sampleConfig = `
 	## List of urls to ping
 	urls = ["www.google.com"]
 
+	## method used to send the pings, only "exec" is available on Windows
+	# method = "exec"
+
 	## number of pings to send per collection (ping -n <COUNT>)
 	# count = 1
 
@@ -148,6 +158,14 @@ func (p *Ping) args(url string) []string {
 }
 
 func (p *Ping) Gather(acc telegraf.Accumulator) error {
+	switch p.Method {
+	case "", "exec":
+	case "native":
+		return errors.New("the native method is not available on Windows")
+	default:
+		return fmt.Errorf("invalid method %q, must be \"exec\"", p.Method)
+	}
+
 	if p.Count < 1 {
 		p.Count = 1
 	}
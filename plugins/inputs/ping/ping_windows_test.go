@@ -333,3 +333,16 @@ func TestTTLExpiredPingGather(t *testing.T) {
 	assert.False(t, acc.HasInt64Field("ping", "minimum_response_ms"),
 		"Fatal ping should not have packet measurements")
 }
+
+func TestPingGatherNativeMethod(t *testing.T) {
+	var acc testutil.Accumulator
+	p := Ping{
+		Urls:     []string{"www.google.com"},
+		Method:   "native",
+		pingHost: mockHostPinger,
+	}
+
+	err := acc.GatherError(p.Gather)
+	assert.Error(t, err)
+	assert.False(t, acc.HasMeasurement("ping"))
+}
@@ -148,11 +148,12 @@ func (psl *packetSocketListener) listen() {
 			break
 		}
 
+		// A parser skipping errors returns the valid metrics of the packet
+		// along with the error.
 		metrics, err := psl.Parse(buf[:n])
 		if err != nil {
 			psl.AddError(fmt.Errorf("unable to parse incoming packet: %s", err))
 			//TODO rate limit
-			continue
 		}
 		for _, m := range metrics {
 			psl.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
@@ -0,0 +1,127 @@
+package syslog
+
+import (
+	"time"
+)
+
+// rfc3164Stamp is the layout of the RFC3164 timestamps, which have no year
+// and no time zone.
+const rfc3164Stamp = "Jan _2 15:04:05"
+
+// parseRFC3164 parses a message in the BSD syslog format of RFC3164:
+//
+//	<PRI>TIMESTAMP HOSTNAME TAG[PID]: MSG
+//
+// Since the format has no year, the year of now is used, or the previous
+// year if the message would be from the future.  The timestamp is taken to
+// be UTC.  Many senders write a RFC3339 timestamp instead, which is accepted
+// too.
+//
+// In best effort mode, a message with an invalid header is accepted, and its
+// content after the priority is used as message.
+func parseRFC3164(buf []byte, now time.Time, bestEffort bool) (*syslogMessage, error) {
+	c := &cursor{buf: buf}
+	m := &syslogMessage{}
+
+	var err error
+	m.facility, m.severity, err = c.priority()
+	if err != nil {
+		return nil, err
+	}
+
+	start := c.pos
+	if err := m.parseRFC3164Header(c, now); err != nil {
+		if !bestEffort {
+			return nil, err
+		}
+		c.pos = start
+		m.timestamp = nil
+		m.hostname = ""
+	}
+
+	m.parseRFC3164Tag(c)
+	m.message = string(c.rest())
+	return m, nil
+}
+
+func (m *syslogMessage) parseRFC3164Header(c *cursor, now time.Time) error {
+	n := len(rfc3164Stamp)
+	if end := c.pos + n; end <= len(c.buf) && (end == len(c.buf) || c.buf[end] == ' ') {
+		t, err := time.Parse(rfc3164Stamp, string(c.buf[c.pos:end]))
+		if err == nil {
+			t = t.AddDate(now.Year(), 0, 0)
+			// Messages from the end of December received in January.
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			m.timestamp = &t
+			c.pos = end
+		}
+	}
+	if m.timestamp == nil {
+		ts := c.token()
+		t, err := time.Parse(time.RFC3339Nano, string(ts))
+		if err != nil {
+			c.pos -= len(ts)
+			return c.errorf("expecting a timestamp")
+		}
+		m.timestamp = &t
+	}
+
+	if err := c.expect(' ', "a space"); err != nil {
+		return err
+	}
+	hostname := c.token()
+	if len(hostname) == 0 || !isPrintUSASCII(hostname) {
+		c.pos -= len(hostname)
+		return c.errorf("expecting a hostname")
+	}
+	m.hostname = string(hostname)
+
+	if !c.done() {
+		if err := c.expect(' ', "a space"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseRFC3164Tag reads the optional "TAG[PID]: " at the start of the
+// message, in which the tag is the name of the program.  The content is left
+// in the message unless it is followed by a colon.
+func (m *syslogMessage) parseRFC3164Tag(c *cursor) {
+	start := c.pos
+	for !c.done() {
+		b := c.buf[c.pos]
+		if b == '[' || b == ':' || b < 33 || b > 126 {
+			break
+		}
+		c.pos++
+	}
+	if c.pos == start {
+		return
+	}
+	tag := string(c.buf[start:c.pos])
+
+	var procid string
+	if c.skip('[') {
+		pidStart := c.pos
+		for !c.done() && c.buf[c.pos] != ']' && c.buf[c.pos] != ' ' {
+			c.pos++
+		}
+		if c.pos == pidStart || !c.skip(']') {
+			c.pos = start
+			return
+		}
+		procid = string(c.buf[pidStart : c.pos-1])
+	}
+
+	if !c.skip(':') {
+		c.pos = start
+		return
+	}
+	c.skip(' ')
+
+	m.appname = tag
+	m.procid = procid
+}
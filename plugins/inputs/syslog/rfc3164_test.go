@@ -0,0 +1,111 @@
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRFC3164(t *testing.T) {
+	now := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		input   string
+		message *syslogMessage
+		err     string
+	}{
+		{
+			name:  "complete",
+			input: "<34>Oct 11 22:14:15 mymachine su[1234]: 'su root' failed for lonvick on /dev/pts/8",
+			message: &syslogMessage{
+				facility:  4,
+				severity:  2,
+				timestamp: timePtr(time.Date(2017, 10, 11, 22, 14, 15, 0, time.UTC)),
+				hostname:  "mymachine",
+				appname:   "su",
+				procid:    "1234",
+				message:   "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			name:  "single digit day",
+			input: "<13>Feb  5 17:32:18 10.0.0.99 Use the BFG!",
+			message: &syslogMessage{
+				facility:  1,
+				severity:  5,
+				timestamp: timePtr(time.Date(2018, 2, 5, 17, 32, 18, 0, time.UTC)),
+				hostname:  "10.0.0.99",
+				message:   "Use the BFG!",
+			},
+		},
+		{
+			name:  "tag without pid",
+			input: "<13>Feb  5 17:32:18 host sshd: session opened",
+			message: &syslogMessage{
+				facility:  1,
+				severity:  5,
+				timestamp: timePtr(time.Date(2018, 2, 5, 17, 32, 18, 0, time.UTC)),
+				hostname:  "host",
+				appname:   "sshd",
+				message:   "session opened",
+			},
+		},
+		{
+			name:  "rfc3339 timestamp",
+			input: "<13>2018-02-05T17:32:18.5+01:00 host cron[42]: job done",
+			message: &syslogMessage{
+				facility:  1,
+				severity:  5,
+				timestamp: timePtr(time.Date(2018, 2, 5, 16, 32, 18, 500000000, time.UTC)),
+				hostname:  "host",
+				appname:   "cron",
+				procid:    "42",
+				message:   "job done",
+			},
+		},
+		{
+			name:  "invalid timestamp",
+			input: "<13>yesterday host message",
+			err:   "expecting a timestamp at offset 4",
+		},
+		{
+			name:  "invalid priority",
+			input: "13>Feb  5 17:32:18 host message",
+			err:   "expecting '<' at offset 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := parseRFC3164([]byte(tt.input), now, false)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				require.Nil(t, m)
+				return
+			}
+			require.NoError(t, err)
+			// Compare the instants, regardless of the time zones.
+			if tt.message.timestamp != nil {
+				require.NotNil(t, m.timestamp)
+				require.True(t, tt.message.timestamp.Equal(*m.timestamp),
+					"%s != %s", tt.message.timestamp, m.timestamp)
+				m.timestamp = tt.message.timestamp
+			}
+			require.Equal(t, tt.message, m)
+		})
+	}
+}
+
+func TestParseRFC3164BestEffort(t *testing.T) {
+	now := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	m, err := parseRFC3164([]byte("<13>yesterday host message"), now, true)
+	require.NoError(t, err)
+	require.Equal(t, &syslogMessage{
+		facility: 1,
+		severity: 5,
+		message:  "yesterday host message",
+	}, m)
+}
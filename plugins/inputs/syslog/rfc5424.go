@@ -0,0 +1,297 @@
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// syslogMessage is a parsed syslog message.  The parts missing from the
+// message, or written as the nil value "-", are left empty.
+type syslogMessage struct {
+	facility uint8
+	severity uint8
+	// version is 0 for RFC3164 messages
+	version   uint16
+	timestamp *time.Time
+	hostname  string
+	appname   string
+	procid    string
+	msgid     string
+	// structuredData maps the SD-IDs to their parameters
+	structuredData map[string]map[string]string
+	message        string
+}
+
+// cursor reads a message, keeping track of the position for errors.
+type cursor struct {
+	buf []byte
+	pos int
+}
+
+func (c *cursor) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("%s at offset %d", fmt.Sprintf(format, a...), c.pos)
+}
+
+func (c *cursor) done() bool {
+	return c.pos >= len(c.buf)
+}
+
+func (c *cursor) peek() byte {
+	if c.done() {
+		return 0
+	}
+	return c.buf[c.pos]
+}
+
+func (c *cursor) skip(b byte) bool {
+	if c.done() || c.buf[c.pos] != b {
+		return false
+	}
+	c.pos++
+	return true
+}
+
+func (c *cursor) expect(b byte, what string) error {
+	if !c.skip(b) {
+		return c.errorf("expecting %s", what)
+	}
+	return nil
+}
+
+// token reads up to the next space or the end of the message.
+func (c *cursor) token() []byte {
+	start := c.pos
+	for !c.done() && c.buf[c.pos] != ' ' {
+		c.pos++
+	}
+	return c.buf[start:c.pos]
+}
+
+func (c *cursor) rest() []byte {
+	rest := c.buf[c.pos:]
+	c.pos = len(c.buf)
+	return rest
+}
+
+// priority reads the "<PRI>" header, common to both formats.
+func (c *cursor) priority() (facility, severity uint8, err error) {
+	if err := c.expect('<', "'<'"); err != nil {
+		return 0, 0, err
+	}
+
+	start := c.pos
+	prival := 0
+	for c.pos-start < 3 && isDigit(c.peek()) {
+		prival = prival*10 + int(c.buf[c.pos]-'0')
+		c.pos++
+	}
+	switch {
+	case c.pos == start:
+		return 0, 0, c.errorf("expecting a priority value")
+	case c.pos-start > 1 && c.buf[start] == '0':
+		return 0, 0, c.errorf("priority value with leading zeros")
+	case prival > 191:
+		return 0, 0, c.errorf("priority value out of range")
+	}
+
+	if err := c.expect('>', "'>'"); err != nil {
+		return 0, 0, err
+	}
+	return uint8(prival / 8), uint8(prival % 8), nil
+}
+
+// parseRFC5424 parses a message in the RFC5424 format:
+//
+//	<PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+//
+// In best effort mode, the parts of an invalid message read before the
+// error are returned, provided that the priority and version are valid.
+func parseRFC5424(buf []byte, bestEffort bool) (*syslogMessage, error) {
+	c := &cursor{buf: buf}
+	m := &syslogMessage{}
+
+	var err error
+	m.facility, m.severity, err = c.priority()
+	if err != nil {
+		return nil, err
+	}
+
+	start := c.pos
+	for c.pos-start < 3 && isDigit(c.peek()) {
+		m.version = m.version*10 + uint16(c.buf[c.pos]-'0')
+		c.pos++
+	}
+	if c.pos == start || c.buf[start] == '0' {
+		return nil, c.errorf("expecting a version value")
+	}
+
+	if err := m.parseRFC5424Header(c); err != nil {
+		if bestEffort {
+			return m, nil
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *syslogMessage) parseRFC5424Header(c *cursor) error {
+	if err := c.expect(' ', "a space"); err != nil {
+		return err
+	}
+	ts := c.token()
+	if string(ts) != "-" {
+		t, err := time.Parse(time.RFC3339Nano, string(ts))
+		if err != nil {
+			c.pos -= len(ts)
+			return c.errorf("expecting a RFC3339 timestamp")
+		}
+		m.timestamp = &t
+	}
+
+	headers := []struct {
+		value  *string
+		name   string
+		maxLen int
+	}{
+		{&m.hostname, "hostname", 255},
+		{&m.appname, "appname", 48},
+		{&m.procid, "procid", 128},
+		{&m.msgid, "msgid", 32},
+	}
+	for _, h := range headers {
+		if err := c.expect(' ', "a space"); err != nil {
+			return err
+		}
+		value := c.token()
+		if len(value) == 0 || len(value) > h.maxLen || !isPrintUSASCII(value) {
+			c.pos -= len(value)
+			return c.errorf("expecting a %s of 1 to %d printable characters", h.name, h.maxLen)
+		}
+		if string(value) != "-" {
+			*h.value = string(value)
+		}
+	}
+
+	if err := c.expect(' ', "a space"); err != nil {
+		return err
+	}
+	if !c.skip('-') {
+		if err := m.parseStructuredData(c); err != nil {
+			return err
+		}
+	}
+
+	if c.done() {
+		return nil
+	}
+	if err := c.expect(' ', "a space"); err != nil {
+		return err
+	}
+	msg := c.rest()
+	// The message may start with an UTF-8 byte order mark.
+	m.message = string(bytes.TrimPrefix(msg, []byte("\xef\xbb\xbf")))
+	return nil
+}
+
+// parseStructuredData reads the SD-ELEMENTs:
+//
+//	[SD-ID PARAM-NAME="PARAM-VALUE" ...]...
+func (m *syslogMessage) parseStructuredData(c *cursor) error {
+	m.structuredData = make(map[string]map[string]string)
+	if c.peek() != '[' {
+		return c.errorf("expecting structured data")
+	}
+
+	for c.skip('[') {
+		id, err := c.sdName("SD-ID")
+		if err != nil {
+			return err
+		}
+		params, ok := m.structuredData[id]
+		if !ok {
+			params = make(map[string]string)
+			m.structuredData[id] = params
+		}
+
+		for c.skip(' ') {
+			name, err := c.sdName("PARAM-NAME")
+			if err != nil {
+				return err
+			}
+			if err := c.expect('=', "'='"); err != nil {
+				return err
+			}
+			value, err := c.sdValue()
+			if err != nil {
+				return err
+			}
+			params[name] = value
+		}
+
+		if err := c.expect(']', "']'"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sdName reads a SD-ID or a PARAM-NAME, made of 1 to 32 printable
+// characters other than '=', ' ', ']' and '"'.
+func (c *cursor) sdName(what string) (string, error) {
+	start := c.pos
+	for !c.done() {
+		b := c.buf[c.pos]
+		if b < 33 || b > 126 || b == '=' || b == ']' || b == '"' {
+			break
+		}
+		c.pos++
+	}
+	if c.pos == start || c.pos-start > 32 {
+		c.pos = start
+		return "", c.errorf("expecting a %s of 1 to 32 characters", what)
+	}
+	return string(c.buf[start:c.pos]), nil
+}
+
+// sdValue reads a quoted PARAM-VALUE, in which '"', '\' and ']' are escaped
+// with a backslash.
+func (c *cursor) sdValue() (string, error) {
+	if err := c.expect('"', "'\"'"); err != nil {
+		return "", err
+	}
+
+	var value []byte
+	for !c.done() {
+		b := c.buf[c.pos]
+		c.pos++
+		switch b {
+		case '"':
+			return string(value), nil
+		case ']':
+			c.pos--
+			return "", c.errorf("unescaped ']' in PARAM-VALUE")
+		case '\\':
+			next := c.peek()
+			if next == '"' || next == '\\' || next == ']' {
+				c.pos++
+				b = next
+			}
+		}
+		value = append(value, b)
+	}
+	return "", c.errorf("unterminated PARAM-VALUE")
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isPrintUSASCII(buf []byte) bool {
+	for _, b := range buf {
+		if b < 33 || b > 126 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,157 @@
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestParseRFC5424(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		message *syslogMessage
+		err     string
+	}{
+		{
+			name:  "minimal",
+			input: "<1>1 - - - - - -",
+			message: &syslogMessage{
+				facility: 0,
+				severity: 1,
+				version:  1,
+			},
+		},
+		{
+			name:  "complete",
+			input: `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="Application"][examplePriority@32473 class="high"] An application event`,
+			message: &syslogMessage{
+				facility:  20,
+				severity:  5,
+				version:   1,
+				timestamp: timePtr(time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC)),
+				hostname:  "mymachine.example.com",
+				appname:   "evntslog",
+				procid:    "1234",
+				msgid:     "ID47",
+				structuredData: map[string]map[string]string{
+					"exampleSDID@32473": {
+						"iut":         "3",
+						"eventSource": "Application",
+					},
+					"examplePriority@32473": {
+						"class": "high",
+					},
+				},
+				message: "An application event",
+			},
+		},
+		{
+			name:  "message with BOM",
+			input: "<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - \xef\xbb\xbf'su root' failed",
+			message: &syslogMessage{
+				facility:  4,
+				severity:  2,
+				version:   1,
+				timestamp: timePtr(time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC)),
+				hostname:  "mymachine.example.com",
+				appname:   "su",
+				msgid:     "ID47",
+				message:   "'su root' failed",
+			},
+		},
+		{
+			name:  "escaped param value",
+			input: `<1>1 - - - - - [id a="q\"b\\s\]c\d"]`,
+			message: &syslogMessage{
+				facility: 0,
+				severity: 1,
+				version:  1,
+				structuredData: map[string]map[string]string{
+					"id": {"a": `q"b\s]c\d`},
+				},
+			},
+		},
+		{
+			name:  "empty structured data element",
+			input: `<1>1 - - - - - [id]`,
+			message: &syslogMessage{
+				facility:       0,
+				severity:       1,
+				version:        1,
+				structuredData: map[string]map[string]string{"id": {}},
+			},
+		},
+		{
+			name:  "invalid priority",
+			input: "<192>1 - - - - - -",
+			err:   "priority value out of range at offset 4",
+		},
+		{
+			name:  "priority leading zeros",
+			input: "<01>1 - - - - - -",
+			err:   "priority value with leading zeros at offset 3",
+		},
+		{
+			name:  "missing version",
+			input: "<1> - - - - - -",
+			err:   "expecting a version value at offset 3",
+		},
+		{
+			name:  "invalid timestamp",
+			input: "<1>1 2003-10-11 - - - - -",
+			err:   "expecting a RFC3339 timestamp at offset 5",
+		},
+		{
+			name:  "missing structured data",
+			input: "<1>1 - - - - -",
+			err:   "expecting a space at offset 14",
+		},
+		{
+			name:  "unterminated param value",
+			input: `<1>1 - - - - - [id a="b]`,
+			err:   "unescaped ']' in PARAM-VALUE at offset 23",
+		},
+		{
+			name:  "long msgid",
+			input: "<1>1 - - - - 0123456789012345678901234567890123456789 -",
+			err:   "expecting a msgid of 1 to 32 printable characters at offset 13",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := parseRFC5424([]byte(tt.input), false)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				require.Nil(t, m)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.message, m)
+		})
+	}
+}
+
+func TestParseRFC5424BestEffort(t *testing.T) {
+	m, err := parseRFC5424([]byte("<1>1 - host app 1234 ID47 [id a=b] message"), true)
+	require.NoError(t, err)
+	require.Equal(t, &syslogMessage{
+		facility:       0,
+		severity:       1,
+		version:        1,
+		hostname:       "host",
+		appname:        "app",
+		procid:         "1234",
+		msgid:          "ID47",
+		structuredData: map[string]map[string]string{"id": {}},
+	}, m)
+
+	_, err = parseRFC5424([]byte("<1> - host app 1234 ID47 -"), true)
+	require.Error(t, err)
+}
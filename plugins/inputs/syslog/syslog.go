@@ -0,0 +1,491 @@
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const defaultReadTimeout = time.Second * 5
+
+// maxMessageLength is the largest octet-counted message accepted.
+const maxMessageLength = 64 * 1024
+
+// Syslog is a syslog plugin
+type Syslog struct {
+	Address           string             `toml:"server"`
+	TLSAllowedCACerts []string           `toml:"tls_allowed_cacerts"`
+	TLSCert           string             `toml:"tls_cert"`
+	TLSKey            string             `toml:"tls_key"`
+	KeepAlivePeriod   *internal.Duration `toml:"keep_alive_period"`
+	ReadTimeout       *internal.Duration `toml:"read_timeout"`
+	MaxConnections    int                `toml:"max_connections"`
+	SyslogStandard    string             `toml:"syslog_standard"`
+	Framing           string             `toml:"framing"`
+	Trailer           string             `toml:"trailer"`
+	BestEffort        bool               `toml:"best_effort"`
+	Separator         string             `toml:"sdparam_separator"`
+
+	now       func() time.Time
+	parse     func(buf []byte) (*syslogMessage, error)
+	tlsConfig *tls.Config
+
+	mu sync.Mutex
+	wg sync.WaitGroup
+
+	acc         telegraf.Accumulator
+	tcpListener net.Listener
+	udpListener net.PacketConn
+	connections map[string]net.Conn
+}
+
+var sampleConfig = `
+  ## Specify an ip or hostname with port - eg., tcp://localhost:6514, tcp://10.0.0.1:6514
+  ## Protocol, address and port to host the syslog receiver.
+  ## If no host is specified, then localhost is used.
+  ## If no port is specified, 6514 is used (RFC5425#section-4.1).
+  server = "tcp://:6514"
+
+  ## TLS Config
+  # tls_allowed_cacerts = ["/etc/telegraf/ca.pem"]
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+
+  ## Period between keep alive probes.
+  ## 0 disables keep alive probes.
+  ## Defaults to the OS configuration.
+  ## Only applies to stream sockets (e.g. TCP).
+  # keep_alive_period = "5m"
+
+  ## Maximum number of concurrent connections (default = 0).
+  ## 0 means unlimited.
+  ## Only applies to stream sockets (e.g. TCP).
+  # max_connections = 1024
+
+  ## Read timeout is the maximum time allowed for reading a single message (default = 5s).
+  ## 0 means unlimited.
+  # read_timeout = "5s"
+
+  ## The syslog standard of the messages, "RFC5424" or "RFC3164" (default = "RFC5424").
+  # syslog_standard = "RFC5424"
+
+  ## The framing technique with which it is expected that messages are
+  ## transported (default = "octet-counting").  Messages are framed by their
+  ## length with "octet-counting" (RFC5425#section-4.3.1, RFC6587#section-3.4.1),
+  ## or by a trailer with "non-transparent" (RFC6587#section-3.4.2).
+  ## Only applies to stream sockets (e.g. TCP).
+  # framing = "octet-counting"
+
+  ## The trailer to be expected in case of non-transparent framing, "LF" or
+  ## "NUL" (default = "LF").
+  # trailer = "LF"
+
+  ## Whether to parse in best effort mode or not (default = false).
+  ## By default best effort parsing is off.
+  # best_effort = false
+
+  ## Character to prepend to SD-PARAMs (default = "_").
+  ## A syslog message can contain multiple parameters and multiple identifiers within structured data section.
+  ## Eg., [id1 name1="val1" name2="val2"][id2 name1="val1" nameA="valA"]
+  ## For each combination a field is created.
+  ## Its name is created concatenating identifier, sdparam_separator, and parameter name.
+  # sdparam_separator = "_"
+`
+
+// SampleConfig returns sample configuration message
+func (s *Syslog) SampleConfig() string {
+	return sampleConfig
+}
+
+// Description returns the plugin description
+func (s *Syslog) Description() string {
+	return "Accepts syslog channel messages over UDP or TCP"
+}
+
+// Gather ...
+func (s *Syslog) Gather(_ telegraf.Accumulator) error {
+	return nil
+}
+
+// Start starts the service.
+func (s *Syslog) Start(acc telegraf.Accumulator) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch strings.ToUpper(s.SyslogStandard) {
+	case "", "RFC5424":
+		s.parse = func(buf []byte) (*syslogMessage, error) {
+			return parseRFC5424(buf, s.BestEffort)
+		}
+	case "RFC3164":
+		s.parse = func(buf []byte) (*syslogMessage, error) {
+			return parseRFC3164(buf, s.now(), s.BestEffort)
+		}
+	default:
+		return fmt.Errorf("unknown syslog standard %q", s.SyslogStandard)
+	}
+
+	switch s.Framing {
+	case "", "octet-counting", "non-transparent":
+	default:
+		return fmt.Errorf("unknown framing %q", s.Framing)
+	}
+
+	switch strings.ToUpper(s.Trailer) {
+	case "", "LF", "NUL":
+	default:
+		return fmt.Errorf("unknown trailer %q", s.Trailer)
+	}
+
+	scheme, host, err := getAddressParts(s.Address)
+	if err != nil {
+		return err
+	}
+	s.acc = acc
+
+	switch scheme {
+	case "tcp", "tcp4", "tcp6":
+		s.tlsConfig, err = internal.GetServerTLSConfig(s.TLSCert, s.TLSKey, s.TLSAllowedCACerts)
+		if err != nil {
+			return err
+		}
+
+		l, err := net.Listen(scheme, host)
+		if err != nil {
+			return err
+		}
+		s.tcpListener = l
+		s.connections = make(map[string]net.Conn)
+
+		s.wg.Add(1)
+		go s.listenStream()
+	case "udp", "udp4", "udp6":
+		l, err := net.ListenPacket(scheme, host)
+		if err != nil {
+			return err
+		}
+		s.udpListener = l
+
+		s.wg.Add(1)
+		go s.listenPacket()
+	default:
+		return fmt.Errorf("unknown protocol %q in %q", scheme, s.Address)
+	}
+
+	return nil
+}
+
+// Stop cleans up all resources
+func (s *Syslog) Stop() {
+	s.mu.Lock()
+	if s.tcpListener != nil {
+		s.tcpListener.Close()
+	}
+	if s.udpListener != nil {
+		s.udpListener.Close()
+	}
+	for _, c := range s.connections {
+		c.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// getAddressParts returns the address scheme and host, defaulting the port
+// to 6514.
+func getAddressParts(a string) (string, string, error) {
+	parts := strings.SplitN(a, "://", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("missing protocol within address '%s'", a)
+	}
+
+	host := parts[1]
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), "6514")
+	}
+	return parts[0], host, nil
+}
+
+func (s *Syslog) listenPacket() {
+	defer s.wg.Done()
+
+	buf := make([]byte, 64*1024) // 64kb - maximum size of IP packet
+	for {
+		n, addr, err := s.udpListener.ReadFrom(buf)
+		if err != nil {
+			if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
+				s.acc.AddError(err)
+			}
+			break
+		}
+
+		s.store(buf[:n], addr)
+	}
+}
+
+func (s *Syslog) listenStream() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.tcpListener.Accept()
+		if err != nil {
+			if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
+				s.acc.AddError(err)
+			}
+			break
+		}
+
+		s.mu.Lock()
+		if s.MaxConnections > 0 && len(s.connections) >= s.MaxConnections {
+			s.mu.Unlock()
+			conn.Close()
+			continue
+		}
+		s.connections[conn.RemoteAddr().String()] = conn
+		s.mu.Unlock()
+
+		if err := s.setKeepAlive(conn); err != nil {
+			s.acc.AddError(fmt.Errorf("unable to configure keep alive (%s): %s", s.Address, err))
+		}
+
+		if s.tlsConfig != nil {
+			conn = tls.Server(conn, s.tlsConfig)
+		}
+
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+func (s *Syslog) removeConnection(c net.Conn) {
+	s.mu.Lock()
+	delete(s.connections, c.RemoteAddr().String())
+	s.mu.Unlock()
+}
+
+func (s *Syslog) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer s.removeConnection(conn)
+	defer conn.Close()
+
+	var split bufio.SplitFunc
+	if s.Framing == "non-transparent" {
+		trailer := byte('\n')
+		if strings.ToUpper(s.Trailer) == "NUL" {
+			trailer = 0
+		}
+		split = splitTrailer(trailer)
+	} else {
+		split = splitOctetCounting
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxMessageLength+16)
+	scanner.Split(split)
+	for {
+		if s.ReadTimeout != nil && s.ReadTimeout.Duration > 0 {
+			conn.SetReadDeadline(time.Now().Add(s.ReadTimeout.Duration))
+		}
+		if !scanner.Scan() {
+			break
+		}
+		s.store(scanner.Bytes(), conn.RemoteAddr())
+	}
+
+	if err := scanner.Err(); err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			log.Printf("D! Timeout in plugin [inputs.syslog]: %s", err)
+		} else if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
+			s.acc.AddError(err)
+		}
+	}
+}
+
+// splitOctetCounting splits messages framed by their length, as in
+// "MSG-LEN SP SYSLOG-MSG".
+func splitOctetCounting(data []byte, atEOF bool) (int, []byte, error) {
+	sp := bytes.IndexByte(data, ' ')
+	if sp == -1 {
+		if len(data) > len(strconv.Itoa(maxMessageLength)) {
+			return 0, nil, fmt.Errorf("expecting a message length")
+		}
+		if atEOF && len(data) > 0 {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, nil
+	}
+
+	length, err := strconv.Atoi(string(data[:sp]))
+	if err != nil || length <= 0 || data[0] == '0' {
+		return 0, nil, fmt.Errorf("invalid message length %q", data[:sp])
+	}
+	if length > maxMessageLength {
+		return 0, nil, fmt.Errorf("message length %d exceeds the maximum of %d", length, maxMessageLength)
+	}
+
+	end := sp + 1 + length
+	if len(data) < end {
+		if atEOF {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, nil
+	}
+	return end, data[sp+1 : end], nil
+}
+
+// splitTrailer splits messages ending with a trailer, ignoring empty
+// messages.
+func splitTrailer(trailer byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, trailer); i >= 0 {
+			// Accept a trailer preceded by a carriage return, which some
+			// senders write.
+			return i + 1, bytes.TrimSuffix(data[:i], []byte("\r")), nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+func (s *Syslog) store(buf []byte, addr net.Addr) {
+	if len(buf) == 0 {
+		return
+	}
+
+	m, err := s.parse(buf)
+	if err != nil {
+		s.acc.AddError(fmt.Errorf("unable to parse message from %s: %s", addr, err))
+		return
+	}
+
+	tags, fields := s.fields(m)
+	if addr != nil {
+		if host, _, err := net.SplitHostPort(addr.String()); err == nil {
+			tags["source"] = host
+		}
+	}
+	s.acc.AddFields("syslog", fields, tags, s.now())
+}
+
+func (s *Syslog) fields(m *syslogMessage) (map[string]string, map[string]interface{}) {
+	tags := map[string]string{
+		"severity": severityNames[m.severity],
+		"facility": facilityNames[m.facility],
+	}
+	if m.hostname != "" {
+		tags["hostname"] = m.hostname
+	}
+	if m.appname != "" {
+		tags["appname"] = m.appname
+	}
+
+	fields := map[string]interface{}{
+		"severity_code": int(m.severity),
+		"facility_code": int(m.facility),
+	}
+	if m.version > 0 {
+		fields["version"] = int(m.version)
+	}
+	if m.timestamp != nil {
+		fields["timestamp"] = m.timestamp.UnixNano()
+	}
+	if m.procid != "" {
+		fields["procid"] = m.procid
+	}
+	if m.msgid != "" {
+		fields["msgid"] = m.msgid
+	}
+	if m.message != "" {
+		fields["message"] = m.message
+	}
+
+	for id, params := range m.structuredData {
+		fields[id] = true
+		for name, value := range params {
+			fields[id+s.Separator+name] = value
+		}
+	}
+
+	return tags, fields
+}
+
+func (s *Syslog) setKeepAlive(c net.Conn) error {
+	if s.KeepAlivePeriod == nil {
+		return nil
+	}
+	tcpc, ok := c.(*net.TCPConn)
+	if !ok {
+		return fmt.Errorf("cannot set keep alive on a %T connection", c)
+	}
+	if s.KeepAlivePeriod.Duration == 0 {
+		return tcpc.SetKeepAlive(false)
+	}
+	if err := tcpc.SetKeepAlive(true); err != nil {
+		return err
+	}
+	return tcpc.SetKeepAlivePeriod(s.KeepAlivePeriod.Duration)
+}
+
+var severityNames = []string{
+	"emerg",
+	"alert",
+	"crit",
+	"err",
+	"warning",
+	"notice",
+	"info",
+	"debug",
+}
+
+var facilityNames = []string{
+	"kern",
+	"user",
+	"mail",
+	"daemon",
+	"auth",
+	"syslog",
+	"lpr",
+	"news",
+	"uucp",
+	"cron",
+	"authpriv",
+	"ftp",
+	"ntp",
+	"security",
+	"console",
+	"solaris-cron",
+	"local0",
+	"local1",
+	"local2",
+	"local3",
+	"local4",
+	"local5",
+	"local6",
+	"local7",
+}
+
+func init() {
+	inputs.Add("syslog", func() telegraf.Input {
+		return &Syslog{
+			Address:     "tcp://:6514",
+			now:         time.Now,
+			ReadTimeout: &internal.Duration{Duration: defaultReadTimeout},
+			Separator:   "_",
+		}
+	})
+}
@@ -0,0 +1,229 @@
+package syslog
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+var defaultTime = time.Unix(0, 0)
+
+const rfc5424Message = `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="Application"] An application event`
+
+var rfc5424Tags = map[string]string{
+	"severity": "notice",
+	"facility": "local4",
+	"hostname": "mymachine.example.com",
+	"appname":  "evntslog",
+	"source":   "127.0.0.1",
+}
+
+var rfc5424Fields = map[string]interface{}{
+	"version":                       1,
+	"severity_code":                 5,
+	"facility_code":                 20,
+	"timestamp":                     time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC).UnixNano(),
+	"procid":                        "1234",
+	"msgid":                         "ID47",
+	"message":                       "An application event",
+	"exampleSDID@32473":             true,
+	"exampleSDID@32473_iut":         "3",
+	"exampleSDID@32473_eventSource": "Application",
+}
+
+func newTestSyslog(address string) *Syslog {
+	return &Syslog{
+		Address:     address,
+		now:         func() time.Time { return defaultTime },
+		ReadTimeout: &internal.Duration{Duration: defaultReadTimeout},
+		Separator:   "_",
+	}
+}
+
+func TestAddress(t *testing.T) {
+	s := newTestSyslog("localhost:6514")
+	err := s.Start(&testutil.Accumulator{})
+	require.EqualError(t, err, "missing protocol within address 'localhost:6514'")
+
+	s = newTestSyslog("unix:///tmp/syslog.sock")
+	err = s.Start(&testutil.Accumulator{})
+	require.EqualError(t, err, `unknown protocol "unix" in "unix:///tmp/syslog.sock"`)
+
+	scheme, host, err := getAddressParts("tcp://localhost")
+	require.NoError(t, err)
+	require.Equal(t, "tcp", scheme)
+	require.Equal(t, "localhost:6514", host)
+}
+
+func TestInvalidOptions(t *testing.T) {
+	s := newTestSyslog("tcp://127.0.0.1:0")
+	s.Framing = "length"
+	require.EqualError(t, s.Start(&testutil.Accumulator{}), `unknown framing "length"`)
+
+	s = newTestSyslog("tcp://127.0.0.1:0")
+	s.SyslogStandard = "RFC3195"
+	require.EqualError(t, s.Start(&testutil.Accumulator{}), `unknown syslog standard "RFC3195"`)
+}
+
+func TestUDP(t *testing.T) {
+	s := newTestSyslog("udp://127.0.0.1:0")
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Start(acc))
+	defer s.Stop()
+
+	conn, err := net.Dial("udp", s.udpListener.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(rfc5424Message))
+	require.NoError(t, err)
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "syslog", rfc5424Fields, rfc5424Tags)
+}
+
+func TestTCPOctetCounting(t *testing.T) {
+	s := newTestSyslog("tcp://127.0.0.1:0")
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Start(acc))
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", s.tcpListener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Send the message in two frames, and the beginning of the second
+	// message with the first.
+	msg := fmt.Sprintf("%d %s", len(rfc5424Message), rfc5424Message)
+	_, err = conn.Write([]byte(msg + msg[:10]))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte(msg[10:]))
+	require.NoError(t, err)
+
+	acc.Wait(2)
+	require.Equal(t, 2, len(acc.Metrics))
+	acc.AssertContainsTaggedFields(t, "syslog", rfc5424Fields, rfc5424Tags)
+}
+
+func TestTCPNonTransparent(t *testing.T) {
+	s := newTestSyslog("tcp://127.0.0.1:0")
+	s.Framing = "non-transparent"
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Start(acc))
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", s.tcpListener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(rfc5424Message + "\n\n" + rfc5424Message + "\r\n"))
+	require.NoError(t, err)
+
+	acc.Wait(2)
+	require.Equal(t, 2, len(acc.Metrics))
+	acc.AssertContainsTaggedFields(t, "syslog", rfc5424Fields, rfc5424Tags)
+}
+
+func TestTCPRFC3164(t *testing.T) {
+	s := newTestSyslog("tcp://127.0.0.1:0")
+	s.Framing = "non-transparent"
+	s.Trailer = "NUL"
+	s.SyslogStandard = "RFC3164"
+	s.now = func() time.Time { return time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC) }
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Start(acc))
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", s.tcpListener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("<34>Oct 11 22:14:15 mymachine su[42]: 'su root' failed\x00"))
+	require.NoError(t, err)
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "syslog",
+		map[string]interface{}{
+			"severity_code": 2,
+			"facility_code": 4,
+			"timestamp":     time.Date(2017, 10, 11, 22, 14, 15, 0, time.UTC).UnixNano(),
+			"procid":        "42",
+			"message":       "'su root' failed",
+		},
+		map[string]string{
+			"severity": "crit",
+			"facility": "auth",
+			"hostname": "mymachine",
+			"appname":  "su",
+			"source":   "127.0.0.1",
+		},
+	)
+}
+
+func TestInvalidMessage(t *testing.T) {
+	s := newTestSyslog("udp://127.0.0.1:0")
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Start(acc))
+	defer s.Stop()
+
+	conn, err := net.Dial("udp", s.udpListener.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("<999>1 - - - - - -"))
+	require.NoError(t, err)
+
+	acc.WaitError(1)
+	require.Contains(t, acc.Errors[0].Error(), "priority value out of range at offset 4")
+	require.Equal(t, 0, len(acc.Metrics))
+}
+
+func TestInvalidFrame(t *testing.T) {
+	s := newTestSyslog("tcp://127.0.0.1:0")
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Start(acc))
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", s.tcpListener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("abc " + rfc5424Message))
+	require.NoError(t, err)
+
+	acc.WaitError(1)
+	require.EqualError(t, acc.Errors[0], `invalid message length "abc"`)
+}
+
+func TestMaxConnections(t *testing.T) {
+	s := newTestSyslog("tcp://127.0.0.1:0")
+	s.MaxConnections = 1
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Start(acc))
+	defer s.Stop()
+
+	first, err := net.Dial("tcp", s.tcpListener.Addr().String())
+	require.NoError(t, err)
+	defer first.Close()
+	msg := fmt.Sprintf("%d %s", len(rfc5424Message), rfc5424Message)
+	_, err = first.Write([]byte(msg))
+	require.NoError(t, err)
+	acc.Wait(1)
+
+	second, err := net.Dial("tcp", s.tcpListener.Addr().String())
+	require.NoError(t, err)
+	defer second.Close()
+
+	// The connection over the limit is closed by the server.
+	second.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = second.Read(make([]byte, 1))
+	require.Error(t, err)
+	netErr, ok := err.(net.Error)
+	require.False(t, ok && netErr.Timeout(), "connection not closed")
+}
@@ -201,16 +201,16 @@ func (u *UdpListener) udpParser() error {
 			}
 		case packet = <-u.in:
 			metrics, err = u.parser.Parse(packet)
-			if err == nil {
-				for _, m := range metrics {
-					u.acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
-				}
-			} else {
+			if err != nil {
 				u.malformed++
 				if u.malformed == 1 || u.malformed%1000 == 0 {
 					log.Printf(malformedwarn, u.malformed)
 				}
 			}
+			// A parser skipping errors still returns the valid metrics.
+			for _, m := range metrics {
+				u.acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+			}
 		}
 	}
 }
@@ -52,6 +52,9 @@ type AMQP struct {
 	// Use SSL but skip chain & host verification
 	InsecureSkipVerify bool
 
+	// Serialize all the metrics of a routing key as one batch
+	UseBatchFormat bool `toml:"use_batch_format"`
+
 	sync.Mutex
 	c *client
 
@@ -111,6 +114,11 @@ var sampleConfig = `
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   data_format = "influx"
+
+  ## Use batch serialization format instead of line based delimiting.  The
+  ## batch format allows for the production of non line based output formats,
+  ## such as a single JSON document holding all the metrics of a flush.
+  # use_batch_format = false
 `
 
 func (a *AMQP) SetSerializer(serializer serializers.Serializer) {
@@ -237,8 +245,7 @@ func (q *AMQP) Write(metrics []telegraf.Metric) error {
 		return fmt.Errorf("connection is not open")
 	}
 
-	outbuf := make(map[string][]byte)
-
+	batches := make(map[string][]telegraf.Metric)
 	for _, metric := range metrics {
 		var key string
 		if q.RoutingTag != "" {
@@ -246,19 +253,18 @@ func (q *AMQP) Write(metrics []telegraf.Metric) error {
 				key = h
 			}
 		}
+		batches[key] = append(batches[key], metric)
+	}
 
-		buf, err := q.serializer.Serialize(metric)
+	for key, batch := range batches {
+		buf, err := q.serialize(batch)
 		if err != nil {
 			return err
 		}
 
-		outbuf[key] = append(outbuf[key], buf...)
-	}
-
-	for key, buf := range outbuf {
 		// Note that since the channel is not in confirm mode, the absence of
 		// an error does not indicate successful delivery.
-		err := c.channel.Publish(
+		err = c.channel.Publish(
 			q.Exchange, // exchange
 			key,        // routing key
 			false,      // mandatory
@@ -276,6 +282,22 @@ func (q *AMQP) Write(metrics []telegraf.Metric) error {
 	return nil
 }
 
+func (q *AMQP) serialize(metrics []telegraf.Metric) ([]byte, error) {
+	if q.UseBatchFormat {
+		return q.serializer.SerializeBatch(metrics)
+	}
+
+	var buf []byte
+	for _, metric := range metrics {
+		b, err := q.serializer.Serialize(metric)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, b...)
+	}
+	return buf, nil
+}
+
 func (q *AMQP) getClient() *client {
 	q.Lock()
 	defer q.Unlock()
@@ -1,24 +1,50 @@
 package file
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
 type File struct {
-	Files []string
+	Files          []string
+	UseBatchFormat bool `toml:"use_batch_format"`
+	Rewrite        bool
+
+	// ExpirationInterval is how long a series is kept in the rewritten
+	// files after its last metric.
+	ExpirationInterval internal.Duration `toml:"expiration_interval"`
 
 	writers []io.Writer
 	closers []io.Closer
+	// files replaced on each write when Rewrite is set
+	rewriteFiles []string
+
+	// latest metric of each series written to the rewritten files, in the
+	// order the series were first seen
+	series      map[uint64]*series
+	seriesOrder []uint64
 
 	serializer serializers.Serializer
 }
 
+type series struct {
+	metric     telegraf.Metric
+	expiration time.Time
+}
+
 var sampleConfig = `
   ## Files to write to, "stdout" is a specially handled file.
   files = ["stdout", "/tmp/metrics.out"]
@@ -28,6 +54,21 @@ var sampleConfig = `
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   data_format = "influx"
+
+  ## Use batch serialization format instead of line based delimiting.  The
+  ## batch format allows for the production of non line based output formats,
+  ## such as a single JSON document holding all the metrics of a flush.
+  # use_batch_format = false
+
+  ## Replace the content of the files on each write instead of appending to
+  ## them.  The files hold the latest metric of every series, and are written
+  ## to a temporary file which is then renamed, so readers never see a
+  ## partially written file.  stdout is not affected.
+  # rewrite = false
+
+  ## Interval after which a series that received no new metric is removed
+  ## from the rewritten files, 0 == no expiration.
+  # expiration_interval = "60s"
 `
 
 func (f *File) SetSerializer(serializer serializers.Serializer) {
@@ -42,6 +83,8 @@ func (f *File) Connect() error {
 	for _, file := range f.Files {
 		if file == "stdout" {
 			f.writers = append(f.writers, os.Stdout)
+		} else if f.Rewrite {
+			f.rewriteFiles = append(f.rewriteFiles, file)
 		} else {
 			var of *os.File
 			var err error
@@ -87,25 +130,147 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 		return nil
 	}
 
-	var writeErr error = nil
+	var writeErr error
+	if len(f.writers) > 0 {
+		b, err := f.serialize(metrics)
+		if err != nil {
+			return err
+		}
+		writeErr = f.write(b)
+	}
+
+	if len(f.rewriteFiles) > 0 {
+		// A flush can be written in several batches, so the files are
+		// rewritten with the latest metric of every series and not only
+		// with the metrics of this batch.
+		b, err := f.serialize(f.updateSeries(metrics))
+		if err != nil {
+			return err
+		}
+		for _, file := range f.rewriteFiles {
+			if err := rewriteFile(file, b); err != nil {
+				writeErr = fmt.Errorf("E! failed to rewrite file %s: %s", file, err)
+			}
+		}
+	}
+	return writeErr
+}
+
+// serialize returns the metrics in the configured data format.
+func (f *File) serialize(metrics []telegraf.Metric) ([]byte, error) {
+	if f.UseBatchFormat {
+		b, err := f.serializer.SerializeBatch(metrics)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize message: %s", err)
+		}
+		return b, nil
+	}
+
+	var buf bytes.Buffer
 	for _, metric := range metrics {
 		b, err := f.serializer.Serialize(metric)
 		if err != nil {
-			return fmt.Errorf("failed to serialize message: %s", err)
+			return nil, fmt.Errorf("failed to serialize message: %s", err)
 		}
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
 
-		for _, writer := range f.writers {
-			_, err = writer.Write(b)
-			if err != nil && writer != os.Stdout {
-				writeErr = fmt.Errorf("E! failed to write message: %s, %s", b, err)
-			}
+func (f *File) write(b []byte) error {
+	var writeErr error = nil
+	for _, writer := range f.writers {
+		_, err := writer.Write(b)
+		if err != nil && writer != os.Stdout {
+			writeErr = fmt.Errorf("E! failed to write message: %s, %s", b, err)
 		}
 	}
 	return writeErr
 }
 
+// updateSeries records the latest metric of each series, and returns the
+// metrics of all the series that have not expired.
+func (f *File) updateSeries(metrics []telegraf.Metric) []telegraf.Metric {
+	if f.series == nil {
+		f.series = make(map[uint64]*series)
+	}
+
+	now := time.Now()
+	for _, m := range metrics {
+		id := seriesID(m)
+		s, ok := f.series[id]
+		if !ok {
+			s = &series{}
+			f.series[id] = s
+			f.seriesOrder = append(f.seriesOrder, id)
+		} else if m.Time().Before(s.metric.Time()) {
+			continue
+		}
+		s.metric = m
+		s.expiration = now.Add(f.ExpirationInterval.Duration)
+	}
+
+	out := make([]telegraf.Metric, 0, len(f.seriesOrder))
+	order := f.seriesOrder[:0]
+	for _, id := range f.seriesOrder {
+		s := f.series[id]
+		if f.ExpirationInterval.Duration > 0 && now.After(s.expiration) {
+			delete(f.series, id)
+			continue
+		}
+		order = append(order, id)
+		out = append(out, s.metric)
+	}
+	f.seriesOrder = order
+	return out
+}
+
+// seriesID identifies the series of a metric by its name, tags and field
+// keys, so that metrics sharing the name and tags but holding different
+// fields are all kept.
+func seriesID(m telegraf.Metric) uint64 {
+	keys := make([]string, 0, len(m.FieldList()))
+	for _, field := range m.FieldList() {
+		keys = append(keys, field.Key)
+	}
+	sort.Strings(keys)
+
+	h := fnv.New64a()
+	binary.Write(h, binary.BigEndian, m.HashID())
+	for _, key := range keys {
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// rewriteFile atomically replaces the content of a file, by writing to a
+// temporary file in the same directory and renaming it over the file.
+func rewriteFile(file string, b []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file))
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
 func init() {
 	outputs.Add("file", func() telegraf.Output {
-		return &File{}
+		return &File{
+			ExpirationInterval: internal.Duration{Duration: time.Second * 60},
+		}
 	})
 }
@@ -6,10 +6,13 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -40,6 +43,69 @@ func TestFileExistingFile(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestFileBatchFormat(t *testing.T) {
+	s, _ := serializers.NewJsonSerializer(time.Second)
+	fh := tmpFile()
+	f := File{
+		Files:          []string{fh},
+		UseBatchFormat: true,
+		serializer:     s,
+	}
+
+	err := f.Connect()
+	assert.NoError(t, err)
+
+	m := testutil.TestMetric(1, "test1")
+	err = f.Write([]telegraf.Metric{m, m})
+	assert.NoError(t, err)
+
+	validateFile(fh, `{"metrics":[`+
+		`{"fields":{"value":1},"name":"test1","tags":{"tag1":"value1"},"timestamp":1257894000},`+
+		`{"fields":{"value":1},"name":"test1","tags":{"tag1":"value1"},"timestamp":1257894000}`+
+		"]}\n", t)
+
+	err = f.Close()
+	assert.NoError(t, err)
+}
+
+func TestFileRewrite(t *testing.T) {
+	fh := createFile()
+	s, _ := serializers.NewInfluxSerializer()
+	f := File{
+		Files:      []string{fh.Name()},
+		Rewrite:    true,
+		serializer: s,
+	}
+
+	err := f.Connect()
+	assert.NoError(t, err)
+
+	err = f.Write(testutil.MockMetrics())
+	assert.NoError(t, err)
+	validateFile(fh.Name(), expNewFile, t)
+
+	// the series of earlier writes are kept
+	m := testutil.TestMetric(2, "test2")
+	err = f.Write([]telegraf.Metric{m, m})
+	assert.NoError(t, err)
+	validateFile(fh.Name(), expNewFile+
+		"test2,tag1=value1 value=2i 1257894000000000000\n", t)
+
+	// a newer metric replaces the one of its series
+	m, _ = metric.New("test2",
+		map[string]string{"tag1": "value1"},
+		map[string]interface{}{"value": 3},
+		m.Time().Add(time.Second),
+	)
+	err = f.Write([]telegraf.Metric{m})
+	assert.NoError(t, err)
+	validateFile(fh.Name(), expNewFile+
+		"test2,tag1=value1 value=3i 1257894001000000000\n", t)
+
+	err = f.Close()
+	assert.NoError(t, err)
+}
+
 func TestFileNewFile(t *testing.T) {
 	s, _ := serializers.NewInfluxSerializer()
 	fh := tmpFile()
@@ -0,0 +1,163 @@
+package http
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+func getMetric() telegraf.Metric {
+	m, err := metric.New(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"value": 42.0,
+		},
+		time.Unix(0, 0),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func newHTTP(url string) *HTTP {
+	return &HTTP{
+		URL:        url,
+		Timeout:    internal.Duration{Duration: defaultClientTimeout},
+		serializer: influx.NewSerializer(),
+	}
+}
+
+func TestInvalidMethod(t *testing.T) {
+	h := newHTTP("http://127.0.0.1")
+	h.Method = "GET"
+	require.EqualError(t, h.Connect(), `invalid method "GET", must be POST or PUT`)
+
+	h = newHTTP("http://127.0.0.1")
+	h.Method = "put"
+	require.NoError(t, h.Connect())
+	require.Equal(t, "PUT", h.Method)
+}
+
+func TestInvalidContentEncoding(t *testing.T) {
+	h := newHTTP("http://127.0.0.1")
+	h.ContentEncoding = "deflate"
+	require.EqualError(t, h.Connect(), `invalid content encoding "deflate"`)
+}
+
+func TestWrite(t *testing.T) {
+	var body string
+	var header http.Header
+	var method string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		header = r.Header
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		body = string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	h := newHTTP(ts.URL)
+	h.Headers = map[string]string{"X-Special-Header": "Special-Value"}
+	h.Username = "telegraf"
+	h.Password = "secret"
+	require.NoError(t, h.Connect())
+	require.NoError(t, h.Write([]telegraf.Metric{getMetric(), getMetric()}))
+
+	require.Equal(t, "POST", method)
+	require.Equal(t, "cpu value=42 0\ncpu value=42 0\n", body)
+	require.Equal(t, defaultContentType, header.Get("Content-Type"))
+	require.Equal(t, "Special-Value", header.Get("X-Special-Header"))
+	require.Equal(t, "Basic dGVsZWdyYWY6c2VjcmV0", header.Get("Authorization"))
+}
+
+func TestWriteGzip(t *testing.T) {
+	var body string
+	var encoding string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		gr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		b, err := ioutil.ReadAll(gr)
+		require.NoError(t, err)
+		body = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	h := newHTTP(ts.URL)
+	h.ContentEncoding = "gzip"
+	require.NoError(t, h.Connect())
+	require.NoError(t, h.Write([]telegraf.Metric{getMetric()}))
+
+	require.Equal(t, "gzip", encoding)
+	require.Equal(t, "cpu value=42 0\n", body)
+}
+
+func TestWriteBearerToken(t *testing.T) {
+	var authorization string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	tokenFile, err := ioutil.TempFile("", "token")
+	require.NoError(t, err)
+	defer os.Remove(tokenFile.Name())
+	_, err = tokenFile.WriteString("abc123\n")
+	require.NoError(t, err)
+	require.NoError(t, tokenFile.Close())
+
+	h := newHTTP(ts.URL)
+	h.BearerToken = tokenFile.Name()
+	require.NoError(t, h.Connect())
+	require.NoError(t, h.Write([]telegraf.Metric{getMetric()}))
+
+	require.Equal(t, "Bearer abc123", authorization)
+}
+
+func TestWriteStatusCode(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		wantErr    bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusAccepted, false},
+		{http.StatusMultipleChoices, true},
+		{http.StatusBadRequest, true},
+		{http.StatusServiceUnavailable, true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.statusCode), func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer ts.Close()
+
+			h := newHTTP(ts.URL)
+			require.NoError(t, h.Connect())
+			err := h.Write([]telegraf.Metric{getMetric()})
+			if tt.wantErr {
+				require.EqualError(t, err, fmt.Sprintf("when writing to [%s] received status code: %d", ts.URL, tt.statusCode))
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -73,6 +73,18 @@ func (s *MockSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 	return s.SerializeF(metric)
 }
 
+func (s *MockSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf []byte
+	for _, m := range metrics {
+		b, err := s.SerializeF(m)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, b...)
+	}
+	return buf, nil
+}
+
 func TestUDP_NewUDPClientNoURL(t *testing.T) {
 	config := &influxdb.UDPConfig{}
 	_, err := influxdb.NewUDPClient(config)
@@ -59,6 +59,9 @@ type (
 		// SASL Password
 		SASLPassword string `toml:"sasl_password"`
 
+		// Serialize all the metrics of a topic and routing key as one message
+		UseBatchFormat bool `toml:"use_batch_format"`
+
 		tlsConfig tls.Config
 		producer  sarama.SyncProducer
 
@@ -151,6 +154,12 @@ var sampleConfig = `
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   # data_format = "influx"
+
+  ## Use batch serialization format instead of line based delimiting.  The
+  ## batch format allows for the production of non line based output formats,
+  ## such as a single JSON document holding all the metrics of a flush.  One
+  ## message is sent per topic and routing key.
+  # use_batch_format = false
 `
 
 func ValidateTopicSuffixMethod(method string) error {
@@ -248,6 +257,10 @@ func (k *Kafka) Write(metrics []telegraf.Metric) error {
 		return nil
 	}
 
+	if k.UseBatchFormat {
+		return k.writeBatches(metrics)
+	}
+
 	for _, metric := range metrics {
 		buf, err := k.serializer.Serialize(metric)
 		if err != nil {
@@ -273,6 +286,48 @@ func (k *Kafka) Write(metrics []telegraf.Metric) error {
 	return nil
 }
 
+// batchKey identifies the metrics sent together in one message.
+type batchKey struct {
+	topic  string
+	key    string
+	hasKey bool
+}
+
+// writeBatches sends one message for each topic and routing key.
+func (k *Kafka) writeBatches(metrics []telegraf.Metric) error {
+	var keys []batchKey
+	batches := make(map[batchKey][]telegraf.Metric)
+	for _, metric := range metrics {
+		bk := batchKey{topic: k.GetTopicName(metric)}
+		bk.key, bk.hasKey = metric.Tags()[k.RoutingTag]
+		if _, ok := batches[bk]; !ok {
+			keys = append(keys, bk)
+		}
+		batches[bk] = append(batches[bk], metric)
+	}
+
+	for _, bk := range keys {
+		buf, err := k.serializer.SerializeBatch(batches[bk])
+		if err != nil {
+			return err
+		}
+
+		m := &sarama.ProducerMessage{
+			Topic: bk.topic,
+			Value: sarama.ByteEncoder(buf),
+		}
+		if bk.hasKey {
+			m.Key = sarama.StringEncoder(bk.key)
+		}
+
+		_, _, err = k.producer.SendMessage(m)
+		if err != nil {
+			return fmt.Errorf("FAILED to send kafka message: %s\n", err)
+		}
+	}
+	return nil
+}
+
 func init() {
 	outputs.Add("kafka", func() telegraf.Output {
 		return &Kafka{
@@ -0,0 +1,290 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+type Parser struct {
+	MetricName        string
+	HeaderRowCount    int
+	SkipRows          int
+	SkipColumns       int
+	Delimiter         string
+	Comment           string
+	TrimSpace         bool
+	ColumnNames       []string
+	ColumnTypes       []string
+	TagColumns        []string
+	MeasurementColumn string
+	TimestampColumn   string
+	TimestampFormat   string
+	DefaultTags       map[string]string
+
+	// linesRead counts the skipped and header rows consumed by ParseLine,
+	// which are the first lines of the stream.
+	linesRead   int
+	headerNames []string
+}
+
+// Compile checks the configuration of the parser.
+func (p *Parser) Compile() error {
+	if p.HeaderRowCount == 0 && len(p.ColumnNames) == 0 {
+		return fmt.Errorf("either csv_header_row_count or csv_column_names must be set")
+	}
+	if p.HeaderRowCount < 0 || p.SkipRows < 0 || p.SkipColumns < 0 {
+		return fmt.Errorf("csv_header_row_count, csv_skip_rows and csv_skip_columns must not be negative")
+	}
+	if utf8.RuneCountInString(p.Delimiter) > 1 {
+		return fmt.Errorf("csv_delimiter must be a single character, got %q", p.Delimiter)
+	}
+	if utf8.RuneCountInString(p.Comment) > 1 {
+		return fmt.Errorf("csv_comment must be a single character, got %q", p.Comment)
+	}
+	if len(p.ColumnTypes) > 0 && len(p.ColumnNames) > 0 &&
+		len(p.ColumnTypes) != len(p.ColumnNames) {
+		return fmt.Errorf("csv_column_names and csv_column_types must have the same length")
+	}
+	for _, t := range p.ColumnTypes {
+		switch t {
+		case "int", "float", "bool", "string":
+		default:
+			return fmt.Errorf("invalid column type %q, must be one of int, float, bool or string", t)
+		}
+	}
+	if p.TimestampColumn != "" && p.TimestampFormat == "" {
+		return fmt.Errorf("csv_timestamp_column requires csv_timestamp_format")
+	}
+	return nil
+}
+
+func (p *Parser) newReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	if p.Delimiter != "" {
+		reader.Comma, _ = utf8.DecodeRuneInString(p.Delimiter)
+	}
+	if p.Comment != "" {
+		reader.Comment, _ = utf8.DecodeRuneInString(p.Comment)
+	}
+	reader.TrimLeadingSpace = p.TrimSpace
+	// Skipped and header rows may not have the number of columns of the
+	// data.
+	reader.FieldsPerRecord = -1
+	return reader
+}
+
+// Parse parses a whole CSV document, starting with the skipped and header
+// rows.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	reader := p.newReader(bytes.NewReader(buf))
+
+	for i := 0; i < p.SkipRows; i++ {
+		if _, err := reader.Read(); err != nil {
+			if err == io.EOF {
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+
+	var headers [][]string
+	for i := 0; i < p.HeaderRowCount; i++ {
+		header, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil, nil
+			}
+			return nil, err
+		}
+		headers = append(headers, header)
+	}
+	columnNames := p.columnNames(headers)
+
+	var metrics []telegraf.Metric
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		m, err := p.parseRecord(columnNames, record)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// ParseLine parses a line of a CSV stream.  The skipped and header rows are
+// the first lines given to the parser, for which no metric is returned.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	record, err := p.newReader(strings.NewReader(line)).Read()
+	if err == io.EOF {
+		// empty or comment line
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.linesRead < p.SkipRows+p.HeaderRowCount {
+		if p.linesRead >= p.SkipRows {
+			p.headerNames = concatHeader(p.headerNames, p.skipColumns(record))
+		}
+		p.linesRead++
+		return nil, nil
+	}
+
+	columnNames := p.ColumnNames
+	if len(columnNames) == 0 {
+		columnNames = p.headerNames
+	}
+	return p.parseRecord(columnNames, record)
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// columnNames returns the configured column names, or else the names made
+// from the header rows.
+func (p *Parser) columnNames(headers [][]string) []string {
+	if len(p.ColumnNames) > 0 {
+		return p.ColumnNames
+	}
+	var names []string
+	for _, header := range headers {
+		names = concatHeader(names, p.skipColumns(header))
+	}
+	return names
+}
+
+// concatHeader appends the names of a header row to the names of the
+// previous header rows.
+func concatHeader(names []string, header []string) []string {
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if i < len(names) {
+			names[i] += name
+		} else {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// skipColumns returns the record without its skipped columns.
+func (p *Parser) skipColumns(record []string) []string {
+	if p.SkipColumns > len(record) {
+		return nil
+	}
+	return record[p.SkipColumns:]
+}
+
+func (p *Parser) parseRecord(columnNames []string, record []string) (telegraf.Metric, error) {
+	if p.SkipColumns > len(record) {
+		return nil, fmt.Errorf("cannot skip %d columns of a %d column record",
+			p.SkipColumns, len(record))
+	}
+	record = p.skipColumns(record)
+
+	name := p.MetricName
+	timestamp := time.Now().UTC()
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	fields := make(map[string]interface{})
+
+outer:
+	for i, value := range record {
+		if i >= len(columnNames) {
+			return nil, fmt.Errorf("record has %d columns, but only %d column names",
+				len(record), len(columnNames))
+		}
+		column := columnNames[i]
+		if column == "" {
+			continue
+		}
+		if p.TrimSpace {
+			value = strings.TrimSpace(value)
+		}
+
+		if column == p.MeasurementColumn {
+			if value != "" {
+				name = value
+			}
+			continue
+		}
+
+		if column == p.TimestampColumn {
+			ts, err := internal.ParseTimestamp(value, p.TimestampFormat)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp in column %q: %s", column, err)
+			}
+			timestamp = ts
+			continue
+		}
+
+		for _, tagName := range p.TagColumns {
+			if tagName == column {
+				tags[column] = value
+				continue outer
+			}
+		}
+
+		if value == "" {
+			continue
+		}
+
+		if len(p.ColumnTypes) > 0 {
+			if i >= len(p.ColumnTypes) {
+				return nil, fmt.Errorf("no type for column %q", column)
+			}
+			v, err := convertValue(value, p.ColumnTypes[i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value in column %q: %s", column, err)
+			}
+			fields[column] = v
+			continue
+		}
+
+		// Without column types, the type is guessed from the value.
+		if iValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+			fields[column] = iValue
+		} else if fValue, err := strconv.ParseFloat(value, 64); err == nil {
+			fields[column] = fValue
+		} else if bValue, err := strconv.ParseBool(value); err == nil {
+			fields[column] = bValue
+		} else {
+			fields[column] = value
+		}
+	}
+
+	return metric.New(name, tags, fields, timestamp)
+}
+
+func convertValue(value string, columnType string) (interface{}, error) {
+	switch columnType {
+	case "int":
+		return strconv.ParseInt(value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "bool":
+		return strconv.ParseBool(value)
+	}
+	return value, nil
+}
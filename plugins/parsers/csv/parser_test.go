@@ -0,0 +1,244 @@
+package csv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBasicCSV(t *testing.T) {
+	p := Parser{
+		ColumnNames: []string{"first", "second", "third"},
+		TagColumns:  []string{"third"},
+	}
+	require.NoError(t, p.Compile())
+
+	m, err := p.ParseLine("1.4,true,hi")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"first": 1.4, "second": true}, m.Fields())
+	require.Equal(t, map[string]string{"third": "hi"}, m.Tags())
+}
+
+func TestHeaderConcatenationCSV(t *testing.T) {
+	p := Parser{
+		HeaderRowCount:    2,
+		MeasurementColumn: "3",
+	}
+	require.NoError(t, p.Compile())
+
+	testCSV := `first,second
+1,2,3
+3.4,70,test_name`
+
+	metrics, err := p.Parse([]byte(testCSV))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, "test_name", metrics[0].Name())
+	require.Equal(t, map[string]interface{}{"first1": 3.4, "second2": int64(70)}, metrics[0].Fields())
+}
+
+func TestHeaderOverride(t *testing.T) {
+	p := Parser{
+		MetricName:        "csv",
+		HeaderRowCount:    1,
+		ColumnNames:       []string{"first", "second", "third"},
+		MeasurementColumn: "third",
+	}
+	require.NoError(t, p.Compile())
+
+	testCSV := `line1,line2,line3
+3.4,70,test_name`
+
+	metrics, err := p.Parse([]byte(testCSV))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, "test_name", metrics[0].Name())
+	require.Equal(t, map[string]interface{}{"first": 3.4, "second": int64(70)}, metrics[0].Fields())
+}
+
+func TestTimestamp(t *testing.T) {
+	p := Parser{
+		MetricName:        "csv",
+		HeaderRowCount:    1,
+		ColumnNames:       []string{"first", "second", "third"},
+		MeasurementColumn: "third",
+		TimestampColumn:   "first",
+		TimestampFormat:   "02/01/06 03:04:05 PM",
+	}
+	require.NoError(t, p.Compile())
+
+	testCSV := `line1,line2,line3
+23/05/09 04:05:06 PM,70,test_name
+07/11/09 04:05:06 PM,80,test_name2`
+
+	metrics, err := p.Parse([]byte(testCSV))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	require.Equal(t, int64(1243094706000000000), metrics[0].Time().UnixNano())
+	require.Equal(t, int64(1257609906000000000), metrics[1].Time().UnixNano())
+	require.Equal(t, map[string]interface{}{"second": int64(70)}, metrics[0].Fields())
+}
+
+func TestTimestampUnix(t *testing.T) {
+	p := Parser{
+		MetricName:      "csv",
+		ColumnNames:     []string{"time", "value"},
+		TimestampColumn: "time",
+		TimestampFormat: "unix_ms",
+	}
+	require.NoError(t, p.Compile())
+
+	m, err := p.ParseLine("1536092344159,42")
+	require.NoError(t, err)
+	require.True(t, time.Unix(1536092344, 159000000).Equal(m.Time()))
+	require.Equal(t, map[string]interface{}{"value": int64(42)}, m.Fields())
+
+	_, err = p.ParseLine("yesterday,42")
+	require.Error(t, err)
+}
+
+func TestQuotedCharacter(t *testing.T) {
+	p := Parser{
+		MetricName:     "csv",
+		HeaderRowCount: 1,
+		ColumnNames:    []string{"first", "second", "third"},
+	}
+	require.NoError(t, p.Compile())
+
+	testCSV := `line1,line2,line3
+"3,4",70,test_name`
+
+	metrics, err := p.Parse([]byte(testCSV))
+	require.NoError(t, err)
+	require.Equal(t, "3,4", metrics[0].Fields()["first"])
+}
+
+func TestDelimiterAndComment(t *testing.T) {
+	p := Parser{
+		MetricName:     "csv",
+		HeaderRowCount: 1,
+		Delimiter:      "%",
+		Comment:        "#",
+		ColumnNames:    []string{"first", "second", "third"},
+	}
+	require.NoError(t, p.Compile())
+
+	testCSV := `line1%line2%line3
+# a comment
+3,4%70%test_name`
+
+	metrics, err := p.Parse([]byte(testCSV))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, "3,4", metrics[0].Fields()["first"])
+}
+
+func TestValueConversion(t *testing.T) {
+	p := Parser{
+		HeaderRowCount: 0,
+		MetricName:     "csv",
+		ColumnNames:    []string{"first", "second", "third", "fourth"},
+		ColumnTypes:    []string{"int", "float", "bool", "string"},
+	}
+	require.NoError(t, p.Compile())
+
+	m, err := p.ParseLine("3,4,true,hello")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"first":  int64(3),
+		"second": float64(4),
+		"third":  true,
+		"fourth": "hello",
+	}, m.Fields())
+
+	_, err = p.ParseLine("3.5,4,true,hello")
+	require.Error(t, err)
+}
+
+func TestTrimSpace(t *testing.T) {
+	p := Parser{
+		MetricName:  "csv",
+		TrimSpace:   true,
+		ColumnNames: []string{"first", "second", "third", "fourth"},
+	}
+	require.NoError(t, p.Compile())
+
+	m, err := p.ParseLine(" 3.3, 4,    true,hello")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"first":  3.3,
+		"second": int64(4),
+		"third":  true,
+		"fourth": "hello",
+	}, m.Fields())
+}
+
+func TestSkipRowsAndColumns(t *testing.T) {
+	p := Parser{
+		MetricName:     "csv",
+		SkipRows:       1,
+		SkipColumns:    1,
+		HeaderRowCount: 1,
+	}
+	require.NoError(t, p.Compile())
+
+	testCSV := `exported by appliance 42
+id,temperature,status
+1,21.5,ok
+2,22,ok`
+
+	metrics, err := p.Parse([]byte(testCSV))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	require.Equal(t, map[string]interface{}{"temperature": 21.5, "status": "ok"}, metrics[0].Fields())
+	require.Equal(t, map[string]interface{}{"temperature": int64(22), "status": "ok"}, metrics[1].Fields())
+}
+
+func TestParseLineStream(t *testing.T) {
+	p := Parser{
+		MetricName:     "csv",
+		SkipRows:       1,
+		HeaderRowCount: 1,
+		TagColumns:     []string{"host"},
+	}
+	require.NoError(t, p.Compile())
+
+	for _, line := range []string{"exported by appliance 42", "host,value"} {
+		m, err := p.ParseLine(line)
+		require.NoError(t, err)
+		require.Nil(t, m)
+	}
+
+	m, err := p.ParseLine("server01,42")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"host": "server01"}, m.Tags())
+	require.Equal(t, map[string]interface{}{"value": int64(42)}, m.Fields())
+}
+
+func TestDefaultTags(t *testing.T) {
+	p := Parser{
+		MetricName:  "csv",
+		ColumnNames: []string{"host", "value"},
+		TagColumns:  []string{"host"},
+	}
+	require.NoError(t, p.Compile())
+	p.SetDefaultTags(map[string]string{"host": "default", "region": "east"})
+
+	m, err := p.ParseLine("server01,42")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"host": "server01", "region": "east"}, m.Tags())
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []Parser{
+		{},
+		{HeaderRowCount: 1, Delimiter: ";;"},
+		{HeaderRowCount: 1, ColumnTypes: []string{"integer"}},
+		{ColumnNames: []string{"a", "b"}, ColumnTypes: []string{"int"}},
+		{HeaderRowCount: 1, TimestampColumn: "time"},
+	}
+	for _, p := range tests {
+		require.Error(t, p.Compile())
+	}
+}
@@ -79,6 +79,28 @@ func TestSimpleParse(t *testing.T) {
 		m.Fields())
 }
 
+func TestParseMultipleLines(t *testing.T) {
+	p := &Parser{
+		Measurement: "syslog",
+		Patterns:    []string{"%{TESTLOG}"},
+		CustomPatterns: `
+			TESTLOG %{NUMBER:num:int} %{WORD:client:tag}
+		`,
+	}
+	assert.NoError(t, p.Compile())
+	p.SetDefaultTags(map[string]string{"host": "localhost", "client": "default"})
+
+	metrics, err := p.Parse([]byte("142 bot\r\n\nnot a match\n143 crawler\n"))
+	assert.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "syslog", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{"num": int64(142)}, metrics[0].Fields())
+	assert.Equal(t, map[string]string{"host": "localhost", "client": "bot"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{"num": int64(143)}, metrics[1].Fields())
+	assert.Equal(t, map[string]string{"host": "localhost", "client": "crawler"}, metrics[1].Tags())
+}
+
 // Verify that patterns with a regex lookahead fail at compile time.
 func TestParsePatternsWithLookahead(t *testing.T) {
 	p := &Parser{
@@ -1,8 +1,10 @@
 package influx
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/influxdata/telegraf"
@@ -10,16 +12,22 @@ import (
 
 const (
 	maxErrorBufferSize = 1024
+
+	// maxErrorLines is the number of lines listed in a ParseErrors message.
+	maxErrorLines = 10
 )
 
 var (
 	ErrNoMetric = errors.New("no metric in line")
 )
 
+// ParseError is an error on a line of the input.  The offset is the position
+// of the error in the input, and the line number starts at 1.
 type ParseError struct {
-	Offset int
-	msg    string
-	buf    string
+	Offset     int
+	LineNumber int
+	msg        string
+	buf        string
 }
 
 func (e *ParseError) Error() string {
@@ -27,12 +35,34 @@ func (e *ParseError) Error() string {
 	if len(buffer) > maxErrorBufferSize {
 		buffer = buffer[:maxErrorBufferSize] + "..."
 	}
-	return fmt.Sprintf("metric parse error: %s at offset %d: %q", e.msg, e.Offset, buffer)
+	return fmt.Sprintf("metric parse error: %s at line %d, offset %d: %q",
+		e.msg, e.LineNumber, e.Offset, buffer)
+}
+
+// ParseErrors is returned along with the valid metrics by a parser skipping
+// errors, and lists the lines that were rejected.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	msgs := make([]string, 0, maxErrorLines+1)
+	for i, err := range e {
+		if i == maxErrorLines {
+			msgs = append(msgs, fmt.Sprintf("and %d more", len(e)-i))
+			break
+		}
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d lines rejected: %s", len(e), strings.Join(msgs, "; "))
 }
 
 type Parser struct {
 	DefaultTags map[string]string
 
+	// SkipErrors continues parsing after an invalid line, instead of
+	// rejecting the whole input.  The rejected lines are returned as
+	// ParseErrors along with the metrics of the valid lines.
+	SkipErrors bool
+
 	sync.Mutex
 	*machine
 	handler *MetricHandler
@@ -51,14 +81,30 @@ func (p *Parser) Parse(input []byte) ([]telegraf.Metric, error) {
 	metrics := make([]telegraf.Metric, 0)
 	p.machine.SetData(input)
 
+	var errs ParseErrors
 	for p.machine.ParseLine() {
 		err := p.machine.Err()
 		if err != nil {
-			return nil, &ParseError{
-				Offset: p.machine.Position(),
-				msg:    err.Error(),
-				buf:    string(input),
+			offset := p.machine.Position()
+			if !p.SkipErrors {
+				return nil, &ParseError{
+					Offset:     offset,
+					LineNumber: lineNumber(input, offset),
+					msg:        err.Error(),
+					buf:        string(input),
+				}
 			}
+
+			// The machine discards the rest of the line on the next call,
+			// but the handler may hold a part of the metric.
+			p.handler.Reset()
+			errs = append(errs, &ParseError{
+				Offset:     offset,
+				LineNumber: lineNumber(input, offset),
+				msg:        err.Error(),
+				buf:        string(lineAt(input, offset)),
+			})
+			continue
 		}
 
 		metric, err := p.handler.Metric()
@@ -70,6 +116,9 @@ func (p *Parser) Parse(input []byte) ([]telegraf.Metric, error) {
 	}
 
 	p.applyDefaultTags(metrics)
+	if len(errs) > 0 {
+		return metrics, errs
+	}
 	return metrics, nil
 }
 
@@ -86,6 +135,27 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	return metrics[0], nil
 }
 
+// lineNumber returns the number of the line holding the offset.
+func lineNumber(input []byte, offset int) int {
+	if offset > len(input) {
+		offset = len(input)
+	}
+	return bytes.Count(input[:offset], []byte("\n")) + 1
+}
+
+// lineAt returns the line holding the offset, without its newline.
+func lineAt(input []byte, offset int) []byte {
+	if offset > len(input) {
+		offset = len(input)
+	}
+	start := bytes.LastIndexByte(input[:offset], '\n') + 1
+	end := bytes.IndexByte(input[offset:], '\n')
+	if end == -1 {
+		return input[start:]
+	}
+	return input[start : offset+end]
+}
+
 func (p *Parser) SetDefaultTags(tags map[string]string) {
 	p.DefaultTags = tags
 }
@@ -497,9 +497,10 @@ var ptests = []struct {
 		input:   []byte("cpu"),
 		metrics: nil,
 		err: &ParseError{
-			Offset: 3,
-			msg:    ErrFieldParse.Error(),
-			buf:    "cpu",
+			Offset:     3,
+			LineNumber: 1,
+			msg:        ErrFieldParse.Error(),
+			buf:        "cpu",
 		},
 	},
 	{
@@ -603,6 +604,63 @@ func TestParser(t *testing.T) {
 	}
 }
 
+func TestParserSkipErrors(t *testing.T) {
+	handler := NewMetricHandler()
+	handler.SetTimeFunc(DefaultTime)
+	parser := NewParser(handler)
+	parser.SkipErrors = true
+
+	input := []byte("cpu value=42\ncpu value=\ncpu,host value=1\ncpu value=43\n")
+	metrics, err := parser.Parse(input)
+	require.Equal(t, ParseErrors{
+		&ParseError{
+			Offset:     23,
+			LineNumber: 2,
+			msg:        ErrFieldParse.Error(),
+			buf:        "cpu value=",
+		},
+		&ParseError{
+			Offset:     32,
+			LineNumber: 3,
+			msg:        ErrTagParse.Error(),
+			buf:        "cpu,host value=1",
+		},
+	}, err)
+
+	require.Equal(t, 2, len(metrics))
+	require.Equal(t, 42.0, metrics[0].Fields()["value"])
+	require.Equal(t, 43.0, metrics[1].Fields()["value"])
+	require.Equal(t, map[string]string{}, metrics[1].Tags())
+}
+
+func TestParserSkipErrorsValid(t *testing.T) {
+	handler := NewMetricHandler()
+	handler.SetTimeFunc(DefaultTime)
+	parser := NewParser(handler)
+	parser.SkipErrors = true
+
+	metrics, err := parser.Parse([]byte("cpu value=42\n"))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(metrics))
+}
+
+func TestParseErrorsMessage(t *testing.T) {
+	var errs ParseErrors
+	for i := 1; i <= 12; i++ {
+		errs = append(errs, &ParseError{
+			Offset:     i * 4,
+			LineNumber: i,
+			msg:        ErrFieldParse.Error(),
+			buf:        "cpu",
+		})
+	}
+	msg := errs.Error()
+	require.Contains(t, msg, "12 lines rejected: ")
+	require.Contains(t, msg, "at line 10, offset 40")
+	require.NotContains(t, msg, "at line 11")
+	require.Contains(t, msg, "; and 2 more")
+}
+
 func BenchmarkParser(b *testing.B) {
 	for _, tt := range ptests {
 		b.Run(tt.name, func(b *testing.B) {
@@ -9,7 +9,10 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/tidwall/gjson"
 )
 
 var (
@@ -20,6 +23,34 @@ type JSONParser struct {
 	MetricName  string
 	TagKeys     []string
 	DefaultTags map[string]string
+
+	// StringFields are the keys of the string values kept as fields, which
+	// may contain globs.  Other string values are dropped.
+	StringFields []string
+	// JSONNameKey is the key of the value used as measurement name.
+	JSONNameKey string
+	// JSONQuery is a gjson path selecting the object or array to parse.
+	JSONQuery string
+	// JSONTimeKey is the key of the value used as metric time, parsed with
+	// JSONTimeFormat.
+	JSONTimeKey    string
+	JSONTimeFormat string
+
+	stringFilter filter.Filter
+	compiled     bool
+}
+
+func (p *JSONParser) compile() error {
+	if p.compiled {
+		return nil
+	}
+	var err error
+	p.stringFilter, err = filter.Compile(p.StringFields)
+	if err != nil {
+		return fmt.Errorf("invalid json_string_fields: %s", err)
+	}
+	p.compiled = true
+	return nil
 }
 
 func (p *JSONParser) parseArray(buf []byte) ([]telegraf.Metric, error) {
@@ -33,6 +64,9 @@ func (p *JSONParser) parseArray(buf []byte) ([]telegraf.Metric, error) {
 	}
 	for _, item := range jsonOut {
 		metrics, err = p.parseObject(metrics, item)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return metrics, nil
 }
@@ -56,13 +90,44 @@ func (p *JSONParser) parseObject(metrics []telegraf.Metric, jsonOut map[string]i
 		delete(jsonOut, tag)
 	}
 
+	name := p.MetricName
+	if p.JSONNameKey != "" {
+		if v, ok := jsonOut[p.JSONNameKey].(string); ok && v != "" {
+			name = v
+		}
+		delete(jsonOut, p.JSONNameKey)
+	}
+
 	f := JSONFlattener{}
-	err := f.FlattenJSON("", jsonOut)
+	err := f.FullFlattenJSON("", jsonOut, true, false)
 	if err != nil {
 		return nil, err
 	}
 
-	metric, err := metric.New(p.MetricName, tags, f.Fields, time.Now().UTC())
+	timestamp := time.Now().UTC()
+	if p.JSONTimeKey != "" {
+		v, ok := f.Fields[p.JSONTimeKey]
+		if !ok {
+			return nil, fmt.Errorf("JSON time key %q could not be found", p.JSONTimeKey)
+		}
+		timestamp, err = internal.ParseTimestamp(v, p.JSONTimeFormat)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse JSON time key %q: %s", p.JSONTimeKey, err)
+		}
+		delete(f.Fields, p.JSONTimeKey)
+	}
+
+	// Only the selected string values are kept as fields.
+	for k, v := range f.Fields {
+		if _, ok := v.(string); !ok {
+			continue
+		}
+		if p.stringFilter == nil || !p.stringFilter.Match(k) {
+			delete(f.Fields, k)
+		}
+	}
+
+	metric, err := metric.New(name, tags, f.Fields, timestamp)
 
 	if err != nil {
 		return nil, err
@@ -71,12 +136,27 @@ func (p *JSONParser) parseObject(metrics []telegraf.Metric, jsonOut map[string]i
 }
 
 func (p *JSONParser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+
 	buf = bytes.TrimSpace(buf)
 	buf = bytes.TrimPrefix(buf, utf8BOM)
 	if len(buf) == 0 {
 		return make([]telegraf.Metric, 0), nil
 	}
 
+	if p.JSONQuery != "" {
+		result := gjson.GetBytes(buf, p.JSONQuery)
+		if !result.Exists() {
+			return nil, fmt.Errorf("JSON query %q did not match", p.JSONQuery)
+		}
+		if result.Type != gjson.JSON {
+			return nil, fmt.Errorf("JSON query %q must select an object or an array", p.JSONQuery)
+		}
+		buf = []byte(result.Raw)
+	}
+
 	if !isarray(buf) {
 		metrics := make([]telegraf.Metric, 0)
 		var jsonOut map[string]interface{}
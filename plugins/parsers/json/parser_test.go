@@ -2,8 +2,10 @@ package json
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -440,3 +442,172 @@ func TestHttpJsonBOM(t *testing.T) {
 	_, err := parser.Parse(jsonBOM)
 	assert.NoError(t, err)
 }
+
+const validJSONStrings = `
+{
+    "a": 5,
+    "name": "cpu",
+    "host": "server01",
+    "state": {
+        "health": "ok",
+        "mode": "standby"
+    },
+    "enabled": true
+}
+`
+
+func TestParseStringFields(t *testing.T) {
+	parser := JSONParser{
+		MetricName:   "json_test",
+		StringFields: []string{"host", "state_*"},
+	}
+
+	metrics, err := parser.Parse([]byte(validJSONStrings))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{
+		"a":            float64(5),
+		"host":         "server01",
+		"state_health": "ok",
+		"state_mode":   "standby",
+	}, metrics[0].Fields())
+}
+
+func TestParseNameKey(t *testing.T) {
+	parser := JSONParser{
+		MetricName:   "json_test",
+		JSONNameKey:  "name",
+		StringFields: []string{"*"},
+	}
+
+	metrics, err := parser.Parse([]byte(validJSONStrings))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, "cpu", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{
+		"a":            float64(5),
+		"host":         "server01",
+		"state_health": "ok",
+		"state_mode":   "standby",
+	}, metrics[0].Fields())
+
+	// The metric name is used when the key is missing.
+	metrics, err = parser.Parse([]byte(validJSON))
+	assert.NoError(t, err)
+	assert.Equal(t, "json_test", metrics[0].Name())
+}
+
+func TestParseTimeKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format string
+	}{
+		{
+			name:   "layout",
+			input:  `{"a": 5, "time": "2018-09-04T20:19:04Z"}`,
+			format: time.RFC3339,
+		},
+		{
+			name:   "nested layout",
+			input:  `{"a": 5, "meta": {"time": "04 Sep 18 20:19 +0000"}}`,
+			format: time.RFC822Z,
+		},
+		{
+			name:   "unix",
+			input:  `{"a": 5, "time": 1536092344}`,
+			format: "unix",
+		},
+		{
+			name:   "unix string",
+			input:  `{"a": 5, "time": "1536092344"}`,
+			format: "unix",
+		},
+		{
+			name:   "unix_ms",
+			input:  `{"a": 5, "time": 1536092344000}`,
+			format: "unix_ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeKey := "time"
+			if tt.name == "nested layout" {
+				timeKey = "meta_time"
+			}
+			parser := JSONParser{
+				MetricName:     "json_test",
+				JSONTimeKey:    timeKey,
+				JSONTimeFormat: tt.format,
+			}
+
+			metrics, err := parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			require.Len(t, metrics, 1)
+			expected := time.Unix(1536092344, 0)
+			if tt.format == time.RFC822Z {
+				expected = time.Unix(1536092340, 0)
+			}
+			assert.True(t, expected.Equal(metrics[0].Time()), "got %s", metrics[0].Time())
+			assert.Equal(t, map[string]interface{}{"a": float64(5)}, metrics[0].Fields())
+		})
+	}
+}
+
+func TestParseTimeKeyErrors(t *testing.T) {
+	parser := JSONParser{
+		MetricName:     "json_test",
+		JSONTimeKey:    "time",
+		JSONTimeFormat: time.RFC3339,
+	}
+
+	_, err := parser.Parse([]byte(`{"a": 5}`))
+	assert.Error(t, err)
+
+	_, err = parser.Parse([]byte(`[{"a": 5, "time": "2018-09-04T20:19:04Z"}, {"a": 6, "time": "yesterday"}]`))
+	assert.Error(t, err)
+}
+
+const validJSONQuery = `
+{
+    "status": "ok",
+    "data": {
+        "cpus": [
+            {"core": "0", "usage": 42.5},
+            {"core": "1", "usage": 12.5}
+        ],
+        "load": {"load1": 0.5}
+    }
+}
+`
+
+func TestParseQuery(t *testing.T) {
+	parser := JSONParser{
+		MetricName: "json_test",
+		TagKeys:    []string{"core"},
+		JSONQuery:  "data.cpus",
+	}
+
+	metrics, err := parser.Parse([]byte(validJSONQuery))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, map[string]interface{}{"usage": 42.5}, metrics[0].Fields())
+	assert.Equal(t, map[string]string{"core": "0"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{"usage": 12.5}, metrics[1].Fields())
+	assert.Equal(t, map[string]string{"core": "1"}, metrics[1].Tags())
+
+	parser.JSONQuery = "data.load"
+	metrics, err = parser.Parse([]byte(validJSONQuery))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{"load1": 0.5}, metrics[0].Fields())
+
+	parser.JSONQuery = "data.missing"
+	_, err = parser.Parse([]byte(validJSONQuery))
+	assert.Error(t, err)
+
+	parser.JSONQuery = "status"
+	_, err = parser.Parse([]byte(validJSONQuery))
+	assert.Error(t, err)
+}
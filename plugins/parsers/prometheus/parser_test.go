@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -165,3 +166,50 @@ func TestParseValidPrometheus(t *testing.T) {
 		metrics[0].Tags())
 
 }
+
+func TestParserValueTypes(t *testing.T) {
+	parser := Parser{}
+
+	metrics, err := parser.Parse([]byte(validData))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 5)
+
+	types := make(map[string]telegraf.ValueType)
+	for _, m := range metrics {
+		types[m.Name()] = m.Type()
+	}
+	assert.Equal(t, map[string]telegraf.ValueType{
+		"cadvisor_version_info":              telegraf.Gauge,
+		"go_gc_duration_seconds":             telegraf.Summary,
+		"http_request_duration_microseconds": telegraf.Summary,
+		"get_token_fail_count":               telegraf.Counter,
+		"apiserver_request_latencies":        telegraf.Histogram,
+	}, types)
+}
+
+func TestParserDefaultTags(t *testing.T) {
+	parser := Parser{}
+	parser.SetDefaultTags(map[string]string{"handler": "default", "host": "localhost"})
+
+	metrics, err := parser.Parse([]byte(validUniqueSummary))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, map[string]string{"handler": "prometheus", "host": "localhost"}, metrics[0].Tags())
+}
+
+func TestParserParseLine(t *testing.T) {
+	parser := Parser{}
+
+	metric, err := parser.ParseLine(`node_textfile_scrape_error{collector="raid"} 0`)
+	assert.NoError(t, err)
+	assert.Equal(t, "node_textfile_scrape_error", metric.Name())
+	assert.Equal(t, map[string]interface{}{"value": float64(0)}, metric.Fields())
+	assert.Equal(t, map[string]string{"collector": "raid"}, metric.Tags())
+	assert.Equal(t, telegraf.Untyped, metric.Type())
+
+	_, err = parser.ParseLine(validUniqueLine)
+	assert.Error(t, err)
+
+	_, err = parser.ParseLine(`not a metric {`)
+	assert.Error(t, err)
+}
@@ -6,11 +6,14 @@ import (
 	"github.com/influxdata/telegraf"
 
 	"github.com/influxdata/telegraf/plugins/parsers/collectd"
+	"github.com/influxdata/telegraf/plugins/parsers/csv"
 	"github.com/influxdata/telegraf/plugins/parsers/dropwizard"
 	"github.com/influxdata/telegraf/plugins/parsers/graphite"
+	"github.com/influxdata/telegraf/plugins/parsers/grok"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
+	"github.com/influxdata/telegraf/plugins/parsers/prometheus"
 	"github.com/influxdata/telegraf/plugins/parsers/value"
 )
 
@@ -42,7 +45,8 @@ type Parser interface {
 // Config is a struct that covers the data types needed for all parser types,
 // and can be used to instantiate _any_ of the parsers.
 type Config struct {
-	// Dataformat can be one of: json, influx, graphite, value, nagios
+	// Dataformat can be one of: json, influx, graphite, value, nagios, grok, csv,
+	// prometheus
 	DataFormat string
 
 	// Separator only applied to Graphite data.
@@ -52,6 +56,16 @@ type Config struct {
 
 	// TagKeys only apply to JSON data
 	TagKeys []string
+	// JSONStringFields are the string values kept as fields in JSON data
+	JSONStringFields []string
+	// JSONNameKey is the key of the measurement name in JSON data
+	JSONNameKey string
+	// JSONQuery is a gjson path selecting the object or array to parse
+	JSONQuery string
+	// JSONTimeKey is the key of the metric time in JSON data, parsed with
+	// JSONTimeFormat: unix, unix_ms, unix_us, unix_ns or a Go time layout
+	JSONTimeKey    string
+	JSONTimeFormat string
 	// MetricName applies to JSON & value. This will be the name of the measurement.
 	MetricName string
 
@@ -68,6 +82,10 @@ type Config struct {
 	// DefaultTags are the default tags that will be added to all parsed metrics.
 	DefaultTags map[string]string
 
+	// InfluxSkipErrors skips the invalid lines of influx data, the metrics of
+	// the valid lines being returned along with the errors.
+	InfluxSkipErrors bool
+
 	// an optional json path containing the metric registry object
 	// if left empty, the whole json object is parsed as a metric registry
 	DropwizardMetricRegistryPath string
@@ -83,6 +101,26 @@ type Config struct {
 	// an optional map containing tag names as keys and json paths to retrieve the tag values from as values
 	// used if TagsPath is empty or doesn't return any tags
 	DropwizardTagPathsMap map[string]string
+
+	// grok patterns
+	GrokPatterns           []string
+	GrokCustomPatterns     string
+	GrokCustomPatternFiles []string
+	GrokTimeZone           string
+
+	// csv configuration
+	CSVHeaderRowCount    int
+	CSVSkipRows          int
+	CSVSkipColumns       int
+	CSVDelimiter         string
+	CSVComment           string
+	CSVTrimSpace         bool
+	CSVColumnNames       []string
+	CSVColumnTypes       []string
+	CSVTagColumns        []string
+	CSVMeasurementColumn string
+	CSVTimestampColumn   string
+	CSVTimestampFormat   string
 }
 
 // NewParser returns a Parser interface based on the given config.
@@ -91,13 +129,19 @@ func NewParser(config *Config) (Parser, error) {
 	var parser Parser
 	switch config.DataFormat {
 	case "json":
-		parser, err = NewJSONParser(config.MetricName,
-			config.TagKeys, config.DefaultTags)
+		parser, err = newJSONParser(config.MetricName,
+			config.TagKeys,
+			config.JSONNameKey,
+			config.JSONStringFields,
+			config.JSONQuery,
+			config.JSONTimeKey,
+			config.JSONTimeFormat,
+			config.DefaultTags)
 	case "value":
 		parser, err = NewValueParser(config.MetricName,
 			config.DataType, config.DefaultTags)
 	case "influx":
-		parser, err = NewInfluxParser()
+		parser, err = newInfluxParser(config.InfluxSkipErrors)
 	case "nagios":
 		parser, err = NewNagiosParser()
 	case "graphite":
@@ -110,12 +154,66 @@ func NewParser(config *Config) (Parser, error) {
 		parser, err = NewDropwizardParser(config.DropwizardMetricRegistryPath,
 			config.DropwizardTimePath, config.DropwizardTimeFormat, config.DropwizardTagsPath, config.DropwizardTagPathsMap, config.DefaultTags,
 			config.Separator, config.Templates)
+	case "prometheus":
+		parser, err = NewPrometheusParser(config.DefaultTags)
+	case "grok":
+		parser, err = newGrokParser(
+			config.MetricName,
+			config.GrokPatterns,
+			config.GrokCustomPatterns,
+			config.GrokCustomPatternFiles,
+			config.GrokTimeZone,
+			config.DefaultTags)
+	case "csv":
+		parser, err = newCSVParser(config.MetricName,
+			config.CSVHeaderRowCount,
+			config.CSVSkipRows,
+			config.CSVSkipColumns,
+			config.CSVDelimiter,
+			config.CSVComment,
+			config.CSVTrimSpace,
+			config.CSVColumnNames,
+			config.CSVColumnTypes,
+			config.CSVTagColumns,
+			config.CSVMeasurementColumn,
+			config.CSVTimestampColumn,
+			config.CSVTimestampFormat,
+			config.DefaultTags)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
 	return parser, err
 }
 
+func newJSONParser(
+	metricName string,
+	tagKeys []string,
+	jsonNameKey string,
+	stringFields []string,
+	jsonQuery string,
+	timeKey string,
+	timeFormat string,
+	defaultTags map[string]string,
+) (Parser, error) {
+	if timeKey != "" && timeFormat == "" {
+		return nil, fmt.Errorf("use of 'json_time_key' requires 'json_time_format'")
+	}
+
+	parser := &json.JSONParser{
+		MetricName:     metricName,
+		TagKeys:        tagKeys,
+		StringFields:   stringFields,
+		JSONNameKey:    jsonNameKey,
+		JSONQuery:      jsonQuery,
+		JSONTimeKey:    timeKey,
+		JSONTimeFormat: timeFormat,
+		DefaultTags:    defaultTags,
+	}
+	return parser, nil
+}
+
+// NewJSONParser returns a JSON parser without the json_ options, for plugins
+// creating their own parser.
 func NewJSONParser(
 	metricName string,
 	tagKeys []string,
@@ -129,13 +227,81 @@ func NewJSONParser(
 	return parser, nil
 }
 
+func newGrokParser(
+	metricName string,
+	patterns []string,
+	customPatterns string,
+	customPatternFiles []string,
+	timezone string,
+	defaultTags map[string]string,
+) (Parser, error) {
+	parser := grok.Parser{
+		Measurement:        metricName,
+		Patterns:           patterns,
+		CustomPatterns:     customPatterns,
+		CustomPatternFiles: customPatternFiles,
+		Timezone:           timezone,
+		DefaultTags:        defaultTags,
+	}
+
+	err := parser.Compile()
+	return &parser, err
+}
+
+func newCSVParser(metricName string,
+	headerRowCount int,
+	skipRows int,
+	skipColumns int,
+	delimiter string,
+	comment string,
+	trimSpace bool,
+	columnNames []string,
+	columnTypes []string,
+	tagColumns []string,
+	nameColumn string,
+	timestampColumn string,
+	timestampFormat string,
+	defaultTags map[string]string,
+) (Parser, error) {
+	parser := &csv.Parser{
+		MetricName:        metricName,
+		HeaderRowCount:    headerRowCount,
+		SkipRows:          skipRows,
+		SkipColumns:       skipColumns,
+		Delimiter:         delimiter,
+		Comment:           comment,
+		TrimSpace:         trimSpace,
+		ColumnNames:       columnNames,
+		ColumnTypes:       columnTypes,
+		TagColumns:        tagColumns,
+		MeasurementColumn: nameColumn,
+		TimestampColumn:   timestampColumn,
+		TimestampFormat:   timestampFormat,
+		DefaultTags:       defaultTags,
+	}
+
+	return parser, parser.Compile()
+}
+
+func NewPrometheusParser(defaultTags map[string]string) (Parser, error) {
+	return &prometheus.Parser{
+		DefaultTags: defaultTags,
+	}, nil
+}
+
 func NewNagiosParser() (Parser, error) {
 	return &nagios.NagiosParser{}, nil
 }
 
 func NewInfluxParser() (Parser, error) {
+	return newInfluxParser(false)
+}
+
+func newInfluxParser(skipErrors bool) (Parser, error) {
 	handler := influx.NewMetricHandler()
-	return influx.NewParser(handler), nil
+	parser := influx.NewParser(handler)
+	parser.SkipErrors = skipErrors
+	return parser, nil
 }
 
 func NewGraphiteParser(
@@ -0,0 +1,317 @@
+package rate
+
+import (
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Fields holding monotonic counters; glob patterns are supported.  All
+  ## the numeric fields of the metrics with the counter value type are
+  ## computed too.
+  fields = ["bytes_*", "packets_*"]
+
+  ## Compute the "rate" per second, or the "delta" between two values.
+  # mode = "rate"
+
+  ## Suffix of the computed fields, "_rate" or "_delta" by default.
+  # suffix = "_rate"
+
+  ## If true, the counter fields are removed once computed.
+  # drop_counters = false
+
+  ## Width in bits of the counters, 32 or 64, to detect their wraparound.
+  ## By default, a decreasing counter is always taken as reset.
+  # counter_bits = 0
+
+  ## Maximum time between two values of a series.  After a longer gap, no
+  ## value is computed, the series starting again.  0 means no limit.
+  # max_gap = "0s"
+`
+
+const (
+	modeRate  = "rate"
+	modeDelta = "delta"
+)
+
+type Rate struct {
+	Fields       []string          `toml:"fields"`
+	Mode         string            `toml:"mode"`
+	Suffix       string            `toml:"suffix"`
+	DropCounters bool              `toml:"drop_counters"`
+	CounterBits  int               `toml:"counter_bits"`
+	MaxGap       internal.Duration `toml:"max_gap"`
+
+	// mu serializes Apply, which the agent calls both for the gathered
+	// metrics and for the metrics of the aggregators.
+	mu          sync.Mutex
+	initialized bool
+	fieldFilter filter.Filter
+	// series holds the last value of the counters of each series
+	series map[uint64]map[string]sample
+	// latest is the time of the latest metric, from which the series
+	// without values for longer than max_gap are removed.
+	latest    time.Time
+	lastPurge time.Time
+}
+
+// sample is a counter value, kept as an unsigned integer when possible to
+// compute exact deltas.
+type sample struct {
+	isInt bool
+	u     uint64
+	f     float64
+	time  time.Time
+}
+
+func NewRate() *Rate {
+	return &Rate{
+		series: make(map[uint64]map[string]sample),
+	}
+}
+
+func (r *Rate) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Rate) Description() string {
+	return "Compute the rate or delta of monotonic counters"
+}
+
+func (r *Rate) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The configuration is only known once the plugin is created, so it is
+	// checked on the first call; invalid settings are logged and replaced by
+	// the defaults.
+	if !r.initialized {
+		r.init()
+		r.initialized = true
+	}
+
+	out := in[:0]
+	for _, m := range in {
+		r.apply(m)
+		if len(m.FieldList()) > 0 {
+			out = append(out, m)
+		}
+	}
+	r.purge()
+	return out
+}
+
+func (r *Rate) init() {
+	var err error
+	r.fieldFilter, err = filter.Compile(r.Fields)
+	if err != nil {
+		log.Printf("E! [processors.rate] Invalid fields: %s\n", err)
+		r.fieldFilter = nil
+	}
+
+	switch r.Mode {
+	case "":
+		r.Mode = modeRate
+	case modeRate, modeDelta:
+	default:
+		log.Printf("E! [processors.rate] Unknown mode %q, using %q\n", r.Mode, modeRate)
+		r.Mode = modeRate
+	}
+	if r.Suffix == "" {
+		r.Suffix = "_" + r.Mode
+	}
+
+	switch r.CounterBits {
+	case 0, 32, 64:
+	default:
+		log.Printf("E! [processors.rate] Unsupported counter_bits %d, must be 32 or 64\n", r.CounterBits)
+		r.CounterBits = 0
+	}
+}
+
+func (r *Rate) apply(m telegraf.Metric) {
+	isCounter := m.Type() == telegraf.Counter
+	if r.fieldFilter == nil && !isCounter {
+		return
+	}
+
+	id := m.HashID()
+	last := r.series[id]
+
+	t := m.Time()
+	if t.After(r.latest) {
+		r.latest = t
+	}
+
+	computed := make(map[string]interface{})
+	var counters []string
+	for _, field := range m.FieldList() {
+		if !isCounter && !r.fieldFilter.Match(field.Key) {
+			continue
+		}
+		cur, ok := newSample(field.Value, t)
+		if !ok {
+			continue
+		}
+		counters = append(counters, field.Key)
+
+		if last == nil {
+			last = make(map[string]sample)
+			r.series[id] = last
+		}
+		prev, ok := last[field.Key]
+		if !ok {
+			last[field.Key] = cur
+			continue
+		}
+		// A value not newer than the previous one, such as the output of
+		// an aggregator stamped with the start of its period, does not
+		// replace it.
+		if !cur.time.After(prev.time) {
+			continue
+		}
+		last[field.Key] = cur
+		if v, ok := r.compute(prev, cur, field.Value); ok {
+			computed[field.Key+r.Suffix] = v
+		}
+	}
+
+	if r.DropCounters {
+		for _, key := range counters {
+			m.RemoveField(key)
+		}
+	}
+	for key, v := range computed {
+		m.AddField(key, v)
+	}
+}
+
+// compute returns the rate or delta between two values of a counter, or
+// false when there is none, such as after a counter reset or a gap.
+func (r *Rate) compute(prev, cur sample, value interface{}) (interface{}, bool) {
+	elapsed := cur.time.Sub(prev.time)
+	if elapsed <= 0 || (r.MaxGap.Duration > 0 && elapsed > r.MaxGap.Duration) {
+		return nil, false
+	}
+
+	var delta float64
+	if prev.isInt && cur.isInt {
+		d, ok := r.intDelta(prev.u, cur.u)
+		if !ok {
+			return nil, false
+		}
+		if r.Mode == modeDelta {
+			// The delta has the type of the counter.
+			if _, ok := value.(uint64); ok {
+				return d, true
+			}
+			if d <= math.MaxInt64 {
+				return int64(d), true
+			}
+			return d, true
+		}
+		delta = float64(d)
+	} else {
+		var ok bool
+		delta, ok = r.floatDelta(prev.value(), cur.value())
+		if !ok {
+			return nil, false
+		}
+		if r.Mode == modeDelta {
+			return delta, true
+		}
+	}
+
+	return delta / elapsed.Seconds(), true
+}
+
+// intDelta returns the increase of an integer counter.  A decrease is taken
+// as a wraparound of a counter of CounterBits bits when the previous value was
+// in the upper half of its range, and as a reset otherwise.
+func (r *Rate) intDelta(prev, cur uint64) (uint64, bool) {
+	if cur >= prev {
+		return cur - prev, true
+	}
+	switch r.CounterBits {
+	case 32:
+		if prev >= 1<<31 && prev <= math.MaxUint32 {
+			return math.MaxUint32 - prev + cur + 1, true
+		}
+	case 64:
+		if prev >= 1<<63 {
+			// The unsigned subtraction wraps around.
+			return cur - prev, true
+		}
+	}
+	return 0, false
+}
+
+func (r *Rate) floatDelta(prev, cur float64) (float64, bool) {
+	if cur >= prev {
+		return cur - prev, true
+	}
+	if r.CounterBits > 0 {
+		max := math.Pow(2, float64(r.CounterBits))
+		if prev >= max/2 && prev < max && cur >= 0 {
+			return max - prev + cur, true
+		}
+	}
+	return 0, false
+}
+
+// purge removes the series without values for longer than max_gap, which
+// would not be computed anymore.
+func (r *Rate) purge() {
+	if r.MaxGap.Duration <= 0 || r.latest.Sub(r.lastPurge) < r.MaxGap.Duration {
+		return
+	}
+	for id, last := range r.series {
+		for key, s := range last {
+			if r.latest.Sub(s.time) > r.MaxGap.Duration {
+				delete(last, key)
+			}
+		}
+		if len(last) == 0 {
+			delete(r.series, id)
+		}
+	}
+	r.lastPurge = r.latest
+}
+
+func newSample(value interface{}, t time.Time) (sample, bool) {
+	switch v := value.(type) {
+	case uint64:
+		return sample{isInt: true, u: v, time: t}, true
+	case int64:
+		if v >= 0 {
+			return sample{isInt: true, u: uint64(v), time: t}, true
+		}
+		return sample{f: float64(v), time: t}, true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return sample{}, false
+		}
+		return sample{f: v, time: t}, true
+	}
+	return sample{}, false
+}
+
+func (s sample) value() float64 {
+	if s.isInt {
+		return float64(s.u)
+	}
+	return s.f
+}
+
+func init() {
+	processors.Add("rate", func() telegraf.Processor {
+		return NewRate()
+	})
+}
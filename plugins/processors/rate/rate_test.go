@@ -0,0 +1,229 @@
+package rate
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+var now = time.Unix(1500000000, 0)
+
+func newMetric(
+	fields map[string]interface{},
+	t time.Time,
+	tp ...telegraf.ValueType,
+) telegraf.Metric {
+	m, err := metric.New("net",
+		map[string]string{"interface": "eth0"},
+		fields,
+		t,
+		tp...,
+	)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestRate(t *testing.T) {
+	r := NewRate()
+	r.Fields = []string{"bytes_*"}
+
+	out := r.Apply(newMetric(map[string]interface{}{"bytes_recv": uint64(1000), "drop_in": int64(1)}, now))
+	require.Equal(t, map[string]interface{}{"bytes_recv": uint64(1000), "drop_in": int64(1)}, out[0].Fields())
+
+	out = r.Apply(newMetric(map[string]interface{}{"bytes_recv": uint64(3000), "drop_in": int64(5)}, now.Add(10*time.Second)))
+	require.Equal(t,
+		map[string]interface{}{"bytes_recv": uint64(3000), "bytes_recv_rate": 200.0, "drop_in": int64(5)},
+		out[0].Fields())
+}
+
+func TestDelta(t *testing.T) {
+	r := NewRate()
+	r.Fields = []string{"*"}
+	r.Mode = "delta"
+	r.DropCounters = true
+
+	out := r.Apply(newMetric(map[string]interface{}{"a": int64(10), "b": uint64(10), "c": 1.5}, now))
+	require.Equal(t, 0, len(out))
+
+	out = r.Apply(newMetric(map[string]interface{}{"a": int64(15), "b": uint64(12), "c": 2.0}, now.Add(time.Minute)))
+	require.Equal(t,
+		map[string]interface{}{"a_delta": int64(5), "b_delta": uint64(2), "c_delta": 0.5},
+		out[0].Fields())
+}
+
+func TestCounterValueType(t *testing.T) {
+	r := NewRate()
+
+	r.Apply(
+		newMetric(map[string]interface{}{"value": int64(10)}, now, telegraf.Counter),
+		newMetric(map[string]interface{}{"value": int64(10)}, now, telegraf.Gauge),
+	)
+	out := r.Apply(
+		newMetric(map[string]interface{}{"value": int64(30)}, now.Add(2*time.Second), telegraf.Counter),
+		newMetric(map[string]interface{}{"value": int64(30)}, now.Add(2*time.Second), telegraf.Gauge),
+	)
+	require.Equal(t, map[string]interface{}{"value": int64(30), "value_rate": 10.0}, out[0].Fields())
+	require.Equal(t, map[string]interface{}{"value": int64(30)}, out[1].Fields())
+}
+
+func TestSeries(t *testing.T) {
+	r := NewRate()
+	r.Fields = []string{"bytes"}
+	r.Mode = "delta"
+
+	m1, err := metric.New("net", map[string]string{"interface": "eth1"},
+		map[string]interface{}{"bytes": int64(100)}, now)
+	require.NoError(t, err)
+	r.Apply(newMetric(map[string]interface{}{"bytes": int64(1)}, now), m1)
+
+	m2, err := metric.New("net", map[string]string{"interface": "eth1"},
+		map[string]interface{}{"bytes": int64(150)}, now.Add(time.Second))
+	require.NoError(t, err)
+	out := r.Apply(newMetric(map[string]interface{}{"bytes": int64(2)}, now.Add(time.Second)), m2)
+
+	require.Equal(t, int64(1), out[0].Fields()["bytes_delta"])
+	require.Equal(t, int64(50), out[1].Fields()["bytes_delta"])
+}
+
+func TestCounterReset(t *testing.T) {
+	r := NewRate()
+	r.Fields = []string{"bytes"}
+	r.Mode = "delta"
+
+	r.Apply(newMetric(map[string]interface{}{"bytes": int64(1000)}, now))
+	out := r.Apply(newMetric(map[string]interface{}{"bytes": int64(10)}, now.Add(time.Second)))
+	require.Equal(t, map[string]interface{}{"bytes": int64(10)}, out[0].Fields())
+
+	// The value after the reset is the new reference.
+	out = r.Apply(newMetric(map[string]interface{}{"bytes": int64(25)}, now.Add(2*time.Second)))
+	require.Equal(t, int64(15), out[0].Fields()["bytes_delta"])
+}
+
+func TestWraparound(t *testing.T) {
+	tests := []struct {
+		name  string
+		bits  int
+		prev  interface{}
+		cur   interface{}
+		delta interface{}
+	}{
+		{"32 bits", 32, uint64(math.MaxUint32 - 9), uint64(5), uint64(15)},
+		{"32 bits reset", 32, uint64(1000), uint64(5), nil},
+		{"32 bits float", 32, float64(math.MaxUint32 - 9), float64(5), 15.0},
+		{"64 bits", 64, uint64(math.MaxUint64 - 9), uint64(5), uint64(15)},
+		{"64 bits reset", 64, uint64(1 << 62), uint64(5), nil},
+		{"no wraparound", 0, uint64(math.MaxUint32 - 9), uint64(5), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRate()
+			r.Fields = []string{"bytes"}
+			r.Mode = "delta"
+			r.CounterBits = tt.bits
+			r.Apply(newMetric(map[string]interface{}{"bytes": tt.prev}, now))
+			out := r.Apply(newMetric(map[string]interface{}{"bytes": tt.cur}, now.Add(time.Second)))
+			delta, ok := out[0].GetField("bytes_delta")
+			if tt.delta == nil {
+				require.False(t, ok)
+			} else {
+				require.Equal(t, tt.delta, delta)
+			}
+		})
+	}
+}
+
+func TestMaxGap(t *testing.T) {
+	r := NewRate()
+	r.Fields = []string{"bytes"}
+	r.MaxGap = internal.Duration{Duration: time.Minute}
+
+	r.Apply(newMetric(map[string]interface{}{"bytes": int64(0)}, now))
+	out := r.Apply(newMetric(map[string]interface{}{"bytes": int64(60)}, now.Add(30*time.Second)))
+	require.Equal(t, 2.0, out[0].Fields()["bytes_rate"])
+
+	out = r.Apply(newMetric(map[string]interface{}{"bytes": int64(600)}, now.Add(5*time.Minute)))
+	_, ok := out[0].GetField("bytes_rate")
+	require.False(t, ok)
+
+	out = r.Apply(newMetric(map[string]interface{}{"bytes": int64(660)}, now.Add(5*time.Minute+10*time.Second)))
+	require.Equal(t, 6.0, out[0].Fields()["bytes_rate"])
+}
+
+func TestMaxGapPurge(t *testing.T) {
+	r := NewRate()
+	r.Fields = []string{"bytes"}
+	r.MaxGap = internal.Duration{Duration: time.Minute}
+
+	m, err := metric.New("net", map[string]string{"interface": "eth1"},
+		map[string]interface{}{"bytes": int64(0)}, now)
+	require.NoError(t, err)
+	r.Apply(m, newMetric(map[string]interface{}{"bytes": int64(0)}, now))
+	require.Equal(t, 2, len(r.series))
+
+	r.Apply(newMetric(map[string]interface{}{"bytes": int64(0)}, now.Add(2*time.Minute)))
+	require.Equal(t, 1, len(r.series))
+}
+
+func TestOutOfOrder(t *testing.T) {
+	r := NewRate()
+	r.Fields = []string{"bytes"}
+
+	r.Apply(newMetric(map[string]interface{}{"bytes": int64(10)}, now))
+	out := r.Apply(newMetric(map[string]interface{}{"bytes": int64(20)}, now))
+	_, ok := out[0].GetField("bytes_rate")
+	require.False(t, ok)
+}
+
+func TestOlderValueKeepsState(t *testing.T) {
+	r := NewRate()
+	r.Fields = []string{"bytes"}
+	r.Mode = "delta"
+
+	r.Apply(newMetric(map[string]interface{}{"bytes": int64(100)}, now.Add(10*time.Second)))
+	// An aggregated value, stamped with the start of its period.
+	r.Apply(newMetric(map[string]interface{}{"bytes": int64(50)}, now))
+	out := r.Apply(newMetric(map[string]interface{}{"bytes": int64(130)}, now.Add(20*time.Second)))
+	require.Equal(t, int64(30), out[0].Fields()["bytes_delta"])
+}
+
+func TestConcurrentApply(t *testing.T) {
+	r := NewRate()
+	r.Fields = []string{"bytes"}
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for j := 0; j < 1000; j++ {
+				r.Apply(newMetric(map[string]interface{}{"bytes": int64(j)},
+					now.Add(time.Duration(j)*time.Second)))
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+}
+
+func TestInvalidConfig(t *testing.T) {
+	r := NewRate()
+	r.Fields = []string{"bytes"}
+	r.Mode = "derivative"
+	r.CounterBits = 16
+	r.Apply(newMetric(map[string]interface{}{"bytes": int64(10)}, now))
+	require.Equal(t, "rate", r.Mode)
+	require.Equal(t, "_rate", r.Suffix)
+	require.Equal(t, 0, r.CounterBits)
+}
@@ -0,0 +1,143 @@
+package regex
+
+import (
+	"log"
+	"regexp"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+type Regex struct {
+	Tags       []converter
+	Fields     []converter
+	regexCache map[string]*regexp.Regexp
+	compile    sync.Once
+}
+
+type converter struct {
+	Key         string
+	Pattern     string
+	Replacement string
+	ResultKey   string
+}
+
+const sampleConfig = `
+  ## Tag and field conversions defined in a separate sub-tables
+  # [[processors.regex.tags]]
+  #   ## Tag to change
+  #   key = "resp_code"
+  #   ## Regular expression to match on a tag value
+  #   pattern = "^(\\d)\\d\\d$"
+  #   ## Pattern for constructing a new value (${1} represents first subgroup)
+  #   replacement = "${1}xx"
+
+  # [[processors.regex.fields]]
+  #   key = "request"
+  #   ## All the power of the Go regular expressions available here
+  #   ## For example, named subgroups
+  #   pattern = "^/api(?P<method>/[\\w/]+)\\S*"
+  #   replacement = "${method}"
+  #   ## If result_key is present, a new field will be created
+  #   ## instead of changing existing field
+  #   result_key = "method"
+
+  ## Multiple conversions may be applied for one field sequentially
+  ## Let's extract one more value
+  # [[processors.regex.fields]]
+  #   key = "request"
+  #   pattern = ".*category=(\\w+).*"
+  #   replacement = "${1}"
+  #   result_key = "search_category"
+`
+
+func NewRegex() *Regex {
+	return &Regex{}
+}
+
+func (r *Regex) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Regex) Description() string {
+	return "Transforms tag and field values with regex pattern"
+}
+
+func (r *Regex) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	r.compile.Do(r.compilePatterns)
+
+	for _, metric := range in {
+		for _, converter := range r.Tags {
+			if value, ok := metric.GetTag(converter.Key); ok {
+				if key, newValue, ok := r.convert(converter, value); ok {
+					metric.AddTag(key, newValue)
+				}
+			}
+		}
+
+		for _, converter := range r.Fields {
+			if value, ok := metric.GetField(converter.Key); ok {
+				// Only string fields can be rewritten.
+				if value, ok := value.(string); ok {
+					if key, newValue, ok := r.convert(converter, value); ok {
+						metric.AddField(key, newValue)
+					}
+				}
+			}
+		}
+	}
+
+	return in
+}
+
+// compilePatterns compiles the patterns of all the converters, once, before
+// the first metric is processed. Apply only reads the cache afterwards, so it
+// can be called concurrently.
+func (r *Regex) compilePatterns() {
+	r.regexCache = make(map[string]*regexp.Regexp)
+	for _, converters := range [][]converter{r.Tags, r.Fields} {
+		for _, c := range converters {
+			if _, ok := r.regexCache[c.Pattern]; ok {
+				continue
+			}
+			regex, err := regexp.Compile(c.Pattern)
+			if err != nil {
+				log.Printf("E! [processors.regex] Invalid pattern %q: %s\n",
+					c.Pattern, err)
+			}
+			// Invalid patterns are cached as well, to be reported only once.
+			r.regexCache[c.Pattern] = regex
+		}
+	}
+}
+
+// convert applies the converter to value, and returns the key and the new
+// value to set. Nothing is set when the pattern is invalid, when it does not
+// match and result_key is set, or when the new value is empty.
+func (r *Regex) convert(c converter, value string) (string, string, bool) {
+	regex := r.regexCache[c.Pattern]
+	if regex == nil {
+		return "", "", false
+	}
+
+	key := c.Key
+	if c.ResultKey != "" {
+		if !regex.MatchString(value) {
+			return "", "", false
+		}
+		key = c.ResultKey
+	}
+
+	newValue := regex.ReplaceAllString(value, c.Replacement)
+	if newValue == "" {
+		return "", "", false
+	}
+	return key, newValue, true
+}
+
+func init() {
+	processors.Add("regex", func() telegraf.Processor {
+		return NewRegex()
+	})
+}
@@ -0,0 +1,92 @@
+package rename
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Measurement, tag and field renamings are applied in the order they are
+  ## defined.  When the new name already exists, its value is replaced.
+  # [[processors.rename.replace]]
+  #   measurement = "network_interface_throughput"
+  #   dest = "throughput"
+
+  # [[processors.rename.replace]]
+  #   tag = "hostname"
+  #   dest = "host"
+
+  # [[processors.rename.replace]]
+  #   field = "lower"
+  #   dest = "min"
+`
+
+type Replace struct {
+	Measurement string `toml:"measurement"`
+	Tag         string `toml:"tag"`
+	Field       string `toml:"field"`
+	Dest        string `toml:"dest"`
+}
+
+type Rename struct {
+	Replaces []Replace `toml:"replace"`
+}
+
+func (r *Rename) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Rename) Description() string {
+	return "Rename measurements, tags, and fields that pass through this filter."
+}
+
+func (r *Rename) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, point := range in {
+		for _, replace := range r.Replaces {
+			if replace.Dest == "" {
+				continue
+			}
+
+			if replace.Measurement != "" {
+				if point.Name() == replace.Measurement {
+					point.SetName(replace.Dest)
+				}
+				continue
+			}
+
+			if replace.Tag != "" {
+				if value, ok := point.GetTag(replace.Tag); ok {
+					if replace.Tag == replace.Dest {
+						continue
+					}
+					// AddTag replaces the value of an existing
+					// destination tag.
+					point.RemoveTag(replace.Tag)
+					point.AddTag(replace.Dest, value)
+				}
+				continue
+			}
+
+			if replace.Field != "" {
+				if value, ok := point.GetField(replace.Field); ok {
+					if replace.Field == replace.Dest {
+						continue
+					}
+					// AddField replaces the value of an existing
+					// destination field.
+					point.RemoveField(replace.Field)
+					point.AddField(replace.Dest, value)
+				}
+				continue
+			}
+		}
+	}
+
+	return in
+}
+
+func init() {
+	processors.Add("rename", func() telegraf.Processor {
+		return &Rename{}
+	})
+}
@@ -0,0 +1,375 @@
+package starlark
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"go.starlark.net/starlark"
+)
+
+// metricDict is the common interface of the tags and fields of a metric,
+// which behave like dictionaries in scripts.
+type metricDict interface {
+	starlark.IterableMapping
+	starlark.HasSetKey
+	Len() int
+	keys() []string
+	remove(key string) (starlark.Value, bool, error)
+}
+
+// dictMethods are the dict methods supported by the tags and fields.
+var dictMethods = map[string]func(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error){
+	"clear":  dictClear,
+	"get":    dictGet,
+	"items":  dictItems,
+	"keys":   dictKeys,
+	"pop":    dictPop,
+	"update": dictUpdate,
+	"values": dictValues,
+}
+
+func dictAttr(d metricDict, name string) (starlark.Value, error) {
+	method, ok := dictMethods[name]
+	if !ok {
+		// Returning nil, nil indicates "no such field or method"
+		return nil, nil
+	}
+	return starlark.NewBuiltin(name,
+		func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			return method(d, b, args, kwargs)
+		}).BindReceiver(d), nil
+}
+
+func dictAttrNames() []string {
+	names := make([]string, 0, len(dictMethods))
+	for name := range dictMethods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func dictString(d metricDict) string {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, item := range d.Items() {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(item[0].String())
+		buf.WriteString(": ")
+		buf.WriteString(item[1].String())
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+func dictItemsOf(d metricDict) []starlark.Tuple {
+	keys := d.keys()
+	items := make([]starlark.Tuple, 0, len(keys))
+	for _, key := range keys {
+		v, _, _ := d.Get(starlark.String(key))
+		items = append(items, starlark.Tuple{starlark.String(key), v})
+	}
+	return items
+}
+
+func dictClear(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	for _, key := range d.keys() {
+		if _, _, err := d.remove(key); err != nil {
+			return nil, err
+		}
+	}
+	return starlark.None, nil
+}
+
+func dictGet(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key, dflt starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &key, &dflt); err != nil {
+		return nil, err
+	}
+	if v, ok, err := d.Get(key); err != nil {
+		return nil, err
+	} else if ok {
+		return v, nil
+	} else if dflt != nil {
+		return dflt, nil
+	}
+	return starlark.None, nil
+}
+
+func dictItems(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	items := d.Items()
+	res := make([]starlark.Value, 0, len(items))
+	for _, item := range items {
+		res = append(res, item)
+	}
+	return starlark.NewList(res), nil
+}
+
+func dictKeys(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	keys := d.keys()
+	res := make([]starlark.Value, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, starlark.String(key))
+	}
+	return starlark.NewList(res), nil
+}
+
+func dictPop(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key, dflt starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &key, &dflt); err != nil {
+		return nil, err
+	}
+	str, ok := key.(starlark.String)
+	if !ok {
+		return nil, fmt.Errorf("%s: key must be a string, not %s", b.Name(), key.Type())
+	}
+	if v, ok, err := d.remove(str.GoString()); err != nil {
+		return nil, err
+	} else if ok {
+		return v, nil
+	} else if dflt != nil {
+		return dflt, nil
+	}
+	return nil, fmt.Errorf("%s: missing key %s", b.Name(), key)
+}
+
+func dictUpdate(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var other starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, nil, 0, &other); err != nil {
+		return nil, err
+	}
+	if other != nil {
+		mapping, ok := other.(starlark.IterableMapping)
+		if !ok {
+			return nil, fmt.Errorf("%s: got %s, want dict", b.Name(), other.Type())
+		}
+		for _, item := range mapping.Items() {
+			if err := d.SetKey(item[0], item[1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, kwarg := range kwargs {
+		if err := d.SetKey(kwarg[0], kwarg[1]); err != nil {
+			return nil, err
+		}
+	}
+	return starlark.None, nil
+}
+
+func dictValues(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	items := d.Items()
+	res := make([]starlark.Value, 0, len(items))
+	for _, item := range items {
+		res = append(res, item[1])
+	}
+	return starlark.NewList(res), nil
+}
+
+// keyIterator iterates over a snapshot of the keys, so that the dictionary
+// can be modified during the iteration.
+type keyIterator struct {
+	keys []string
+}
+
+func (it *keyIterator) Next(p *starlark.Value) bool {
+	if len(it.keys) == 0 {
+		return false
+	}
+	*p = starlark.String(it.keys[0])
+	it.keys = it.keys[1:]
+	return true
+}
+
+func (it *keyIterator) Done() {}
+
+// TagDict is the tags of a metric.
+type TagDict struct {
+	metric *Metric
+}
+
+func (d *TagDict) String() string        { return dictString(d) }
+func (d *TagDict) Type() string          { return "Tags" }
+func (d *TagDict) Freeze()               { d.metric.Freeze() }
+func (d *TagDict) Truth() starlark.Bool  { return len(d.metric.metric.TagList()) > 0 }
+func (d *TagDict) Hash() (uint32, error) { return 0, errors.New("unhashable type: Tags") }
+func (d *TagDict) Len() int              { return len(d.metric.metric.TagList()) }
+func (d *TagDict) Items() []starlark.Tuple {
+	return dictItemsOf(d)
+}
+func (d *TagDict) Iterate() starlark.Iterator {
+	return &keyIterator{keys: d.keys()}
+}
+func (d *TagDict) Attr(name string) (starlark.Value, error) { return dictAttr(d, name) }
+func (d *TagDict) AttrNames() []string                      { return dictAttrNames() }
+
+func (d *TagDict) keys() []string {
+	tags := d.metric.metric.TagList()
+	keys := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys = append(keys, tag.Key)
+	}
+	return keys
+}
+
+func (d *TagDict) Get(key starlark.Value) (starlark.Value, bool, error) {
+	str, ok := key.(starlark.String)
+	if !ok {
+		return nil, false, fmt.Errorf("tag key must be a string, not %s", key.Type())
+	}
+	if v, ok := d.metric.metric.GetTag(str.GoString()); ok {
+		return starlark.String(v), true, nil
+	}
+	return nil, false, nil
+}
+
+func (d *TagDict) SetKey(key, value starlark.Value) error {
+	if d.metric.frozen {
+		return errFrozen
+	}
+	k, ok := key.(starlark.String)
+	if !ok {
+		return fmt.Errorf("tag key must be a string, not %s", key.Type())
+	}
+	v, ok := value.(starlark.String)
+	if !ok {
+		return fmt.Errorf("tag value must be a string, not %s", value.Type())
+	}
+	d.metric.metric.AddTag(k.GoString(), v.GoString())
+	return nil
+}
+
+func (d *TagDict) remove(key string) (starlark.Value, bool, error) {
+	if d.metric.frozen {
+		return nil, false, errFrozen
+	}
+	v, ok := d.metric.metric.GetTag(key)
+	if !ok {
+		return nil, false, nil
+	}
+	d.metric.metric.RemoveTag(key)
+	return starlark.String(v), true, nil
+}
+
+// FieldDict is the fields of a metric. Field values are converted from and to
+// the int, float, bool and string types of the scripts.
+type FieldDict struct {
+	metric *Metric
+}
+
+func (d *FieldDict) String() string        { return dictString(d) }
+func (d *FieldDict) Type() string          { return "Fields" }
+func (d *FieldDict) Freeze()               { d.metric.Freeze() }
+func (d *FieldDict) Truth() starlark.Bool  { return len(d.metric.metric.FieldList()) > 0 }
+func (d *FieldDict) Hash() (uint32, error) { return 0, errors.New("unhashable type: Fields") }
+func (d *FieldDict) Len() int              { return len(d.metric.metric.FieldList()) }
+func (d *FieldDict) Items() []starlark.Tuple {
+	return dictItemsOf(d)
+}
+func (d *FieldDict) Iterate() starlark.Iterator {
+	return &keyIterator{keys: d.keys()}
+}
+func (d *FieldDict) Attr(name string) (starlark.Value, error) { return dictAttr(d, name) }
+func (d *FieldDict) AttrNames() []string                      { return dictAttrNames() }
+
+func (d *FieldDict) keys() []string {
+	fields := d.metric.metric.FieldList()
+	keys := make([]string, 0, len(fields))
+	for _, field := range fields {
+		keys = append(keys, field.Key)
+	}
+	return keys
+}
+
+func (d *FieldDict) Get(key starlark.Value) (starlark.Value, bool, error) {
+	str, ok := key.(starlark.String)
+	if !ok {
+		return nil, false, fmt.Errorf("field key must be a string, not %s", key.Type())
+	}
+	if v, ok := d.metric.metric.GetField(str.GoString()); ok {
+		sv, err := asStarlarkValue(v)
+		return sv, true, err
+	}
+	return nil, false, nil
+}
+
+func (d *FieldDict) SetKey(key, value starlark.Value) error {
+	if d.metric.frozen {
+		return errFrozen
+	}
+	k, ok := key.(starlark.String)
+	if !ok {
+		return fmt.Errorf("field key must be a string, not %s", key.Type())
+	}
+	v, err := asGoValue(value)
+	if err != nil {
+		return err
+	}
+	d.metric.metric.AddField(k.GoString(), v)
+	return nil
+}
+
+func (d *FieldDict) remove(key string) (starlark.Value, bool, error) {
+	if d.metric.frozen {
+		return nil, false, errFrozen
+	}
+	v, ok := d.metric.metric.GetField(key)
+	if !ok {
+		return nil, false, nil
+	}
+	d.metric.metric.RemoveField(key)
+	sv, err := asStarlarkValue(v)
+	return sv, true, err
+}
+
+func asStarlarkValue(v interface{}) (starlark.Value, error) {
+	switch v := v.(type) {
+	case int64:
+		return starlark.MakeInt64(v), nil
+	case uint64:
+		return starlark.MakeUint64(v), nil
+	case float64:
+		return starlark.Float(v), nil
+	case bool:
+		return starlark.Bool(v), nil
+	case string:
+		return starlark.String(v), nil
+	}
+	return nil, fmt.Errorf("unsupported field type %T", v)
+}
+
+func asGoValue(v starlark.Value) (interface{}, error) {
+	switch v := v.(type) {
+	case starlark.Int:
+		if i, ok := v.Int64(); ok {
+			return i, nil
+		}
+		if u, ok := v.Uint64(); ok {
+			return u, nil
+		}
+		return nil, errors.New("field value out of range")
+	case starlark.Float:
+		return float64(v), nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.String:
+		return v.GoString(), nil
+	}
+	return nil, fmt.Errorf("field value must be an int, float, bool or string, not %s", v.Type())
+}
@@ -0,0 +1,150 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"go.starlark.net/starlark"
+)
+
+// Metric exposes a telegraf.Metric to scripts. Its name, tags, fields and
+// time can be read and modified.
+type Metric struct {
+	metric telegraf.Metric
+	frozen bool
+}
+
+var errFrozen = errors.New("cannot modify frozen metric")
+
+func (m *Metric) String() string {
+	return fmt.Sprintf("Metric(%q, tags=%s, fields=%s, time=%d)",
+		m.metric.Name(), m.Tags(), m.Fields(), m.metric.Time().UnixNano())
+}
+
+func (m *Metric) Type() string {
+	return "Metric"
+}
+
+func (m *Metric) Freeze() {
+	m.frozen = true
+}
+
+func (m *Metric) Truth() starlark.Bool {
+	return true
+}
+
+func (m *Metric) Hash() (uint32, error) {
+	return 0, errors.New("unhashable type: Metric")
+}
+
+func (m *Metric) AttrNames() []string {
+	return []string{"name", "tags", "fields", "time"}
+}
+
+func (m *Metric) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "name":
+		return starlark.String(m.metric.Name()), nil
+	case "tags":
+		return m.Tags(), nil
+	case "fields":
+		return m.Fields(), nil
+	case "time":
+		return starlark.MakeInt64(m.metric.Time().UnixNano()), nil
+	}
+	// Returning nil, nil indicates "no such field or method"
+	return nil, nil
+}
+
+func (m *Metric) SetField(name string, value starlark.Value) error {
+	if m.frozen {
+		return errFrozen
+	}
+
+	switch name {
+	case "name":
+		str, ok := value.(starlark.String)
+		if !ok {
+			return fmt.Errorf("type error: name must be a string, not %s", value.Type())
+		}
+		m.metric.SetName(str.GoString())
+		return nil
+	case "time":
+		i, ok := value.(starlark.Int)
+		if !ok {
+			return fmt.Errorf("type error: time must be an int, not %s", value.Type())
+		}
+		ns, ok := i.Int64()
+		if !ok {
+			return errors.New("type error: time out of range")
+		}
+		return m.setTime(time.Unix(0, ns))
+	case "tags", "fields":
+		return fmt.Errorf("cannot set %s, modify its items instead", name)
+	}
+	return starlark.NoSuchAttrError(
+		fmt.Sprintf("cannot assign to field %q", name))
+}
+
+// setTime replaces the metric by one with the new time, since metrics don't
+// allow changing their time.
+func (m *Metric) setTime(t time.Time) error {
+	new, err := metric.New(m.metric.Name(), m.metric.Tags(),
+		m.metric.Fields(), t, m.metric.Type())
+	if err != nil {
+		return err
+	}
+	new.SetAggregate(m.metric.IsAggregate())
+	m.metric = new
+	return nil
+}
+
+func (m *Metric) Tags() *TagDict {
+	return &TagDict{metric: m}
+}
+
+func (m *Metric) Fields() *FieldDict {
+	return &FieldDict{metric: m}
+}
+
+// Unwrap returns the wrapped telegraf.Metric.
+func (m *Metric) Unwrap() telegraf.Metric {
+	return m.metric
+}
+
+// newMetric implements the Metric(name) builtin, creating a metric without
+// tags and fields at the current time.
+func newMetric(
+	thread *starlark.Thread,
+	b *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var name starlark.String
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &name); err != nil {
+		return nil, err
+	}
+
+	m, err := metric.New(name.GoString(), nil, nil, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &Metric{metric: m}, nil
+}
+
+// deepcopy implements the deepcopy(metric) builtin.
+func deepcopy(
+	thread *starlark.Thread,
+	b *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var m *Metric
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &m); err != nil {
+		return nil, err
+	}
+	return &Metric{metric: m.metric.Copy()}, nil
+}
@@ -0,0 +1,195 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+)
+
+const sampleConfig = `
+  ## The Starlark source can be set as a string in this configuration file, or
+  ## by referencing a file containing the script.  Only one source or script
+  ## should be set at once.
+  ##
+  ## Source of the Starlark script.
+  source = '''
+def apply(metric):
+	return metric
+'''
+
+  ## File containing a Starlark script.
+  # script = "/usr/local/bin/myscript.star"
+`
+
+// Starlark runs a Starlark script on each metric. The script must define an
+// apply(metric) function, whose return value replaces the metric: None drops
+// it, and a list of metrics splits it or emits new ones.
+type Starlark struct {
+	Source string `toml:"source"`
+	Script string `toml:"script"`
+
+	// mu serializes the calls to the script, which share the thread and
+	// the state dictionary.
+	mu        sync.Mutex
+	thread    *starlark.Thread
+	applyFunc *starlark.Function
+	err       error
+}
+
+func (s *Starlark) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Starlark) Description() string {
+	return "Process metrics using a Starlark script"
+}
+
+// setup loads the script, once, before the first metric is processed.
+func (s *Starlark) setup() error {
+	if s.thread != nil || s.err != nil {
+		return s.err
+	}
+	s.err = s.load()
+	if s.err != nil {
+		log.Printf("E! [processors.starlark] %s\n", s.err)
+	}
+	return s.err
+}
+
+func (s *Starlark) load() error {
+	if s.Source == "" && s.Script == "" {
+		return errors.New("one of source or script must be set")
+	}
+	if s.Source != "" && s.Script != "" {
+		return errors.New("only one of source or script can be set")
+	}
+
+	thread := &starlark.Thread{
+		Name: "processors.starlark",
+		Print: func(_ *starlark.Thread, msg string) {
+			log.Printf("I! [processors.starlark] %s\n", msg)
+		},
+		// loading other modules is not allowed
+		Load: func(_ *starlark.Thread, module string) (starlark.StringDict, error) {
+			return nil, fmt.Errorf("cannot load %s, loading modules is not supported", module)
+		},
+	}
+
+	// The state dictionary is not frozen with the rest of the globals, so
+	// the script can use it to keep values between calls.
+	builtins := starlark.StringDict{
+		"Metric":   starlark.NewBuiltin("Metric", newMetric),
+		"deepcopy": starlark.NewBuiltin("deepcopy", deepcopy),
+		"state":    starlark.NewDict(0),
+	}
+
+	filename, src := s.Script, interface{}(nil)
+	if s.Source != "" {
+		filename, src = "processors.starlark", s.Source
+	}
+	globals, err := starlark.ExecFile(thread, filename, src, builtins)
+	if err != nil {
+		if evalErr, ok := err.(*starlark.EvalError); ok {
+			return errors.New(evalErr.Backtrace())
+		}
+		return err
+	}
+
+	apply, ok := globals["apply"]
+	if !ok {
+		return errors.New("apply is not defined")
+	}
+	fn, ok := apply.(*starlark.Function)
+	if !ok {
+		return fmt.Errorf("apply is not a function, but %s", apply.Type())
+	}
+	if fn.NumParams() != 1 {
+		return errors.New("apply function must take one parameter")
+	}
+
+	s.thread = thread
+	s.applyFunc = fn
+	return nil
+}
+
+func (s *Starlark) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.setup(); err != nil {
+		return in
+	}
+
+	out := make([]telegraf.Metric, 0, len(in))
+	for _, m := range in {
+		args := starlark.Tuple{&Metric{metric: m}}
+		rv, err := starlark.Call(s.thread, s.applyFunc, args, nil)
+		if err != nil {
+			if evalErr, ok := err.(*starlark.EvalError); ok {
+				err = errors.New(evalErr.Backtrace())
+			}
+			log.Printf("E! [processors.starlark] Error calling apply: %s\n", err)
+			out = append(out, m)
+			continue
+		}
+
+		metrics, err := asMetrics(rv)
+		if err != nil {
+			log.Printf("E! [processors.starlark] Invalid return value of apply: %s\n", err)
+			out = append(out, m)
+			continue
+		}
+		out = append(out, metrics...)
+	}
+	return out
+}
+
+// asMetrics converts the value returned by the apply function.
+func asMetrics(rv starlark.Value) ([]telegraf.Metric, error) {
+	switch rv := rv.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case *Metric:
+		return []telegraf.Metric{rv.Unwrap()}, nil
+	case *starlark.List:
+		metrics := make([]telegraf.Metric, 0, rv.Len())
+		seen := make(map[telegraf.Metric]bool, rv.Len())
+		iter := rv.Iterate()
+		defer iter.Done()
+		var v starlark.Value
+		for iter.Next(&v) {
+			m, ok := v.(*Metric)
+			if !ok {
+				return nil, fmt.Errorf("list must only contain metrics, not %s", v.Type())
+			}
+			// The same metric returned twice must not be shared by both.
+			if seen[m.Unwrap()] {
+				metrics = append(metrics, m.Unwrap().Copy())
+				continue
+			}
+			seen[m.Unwrap()] = true
+			metrics = append(metrics, m.Unwrap())
+		}
+		return metrics, nil
+	}
+	return nil, fmt.Errorf("must be a Metric, a list of metrics or None, not %s", rv.Type())
+}
+
+func init() {
+	// Metric fields are often floats. Recursion and while loops stay
+	// disabled, so that a script always terminates.
+	resolve.AllowFloat = true
+	resolve.AllowLambda = true
+	resolve.AllowNestedDef = true
+	resolve.AllowSet = true
+
+	processors.Add("starlark", func() telegraf.Processor {
+		return &Starlark{}
+	})
+}
@@ -0,0 +1,269 @@
+package starlark
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMetric(t *testing.T) telegraf.Metric {
+	m, err := metric.New("cpu",
+		map[string]string{"host": "example.org"},
+		map[string]interface{}{"time_idle": 42.0, "count": int64(1)},
+		time.Unix(0, 0),
+	)
+	require.NoError(t, err)
+	return m
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin *Starlark
+	}{
+		{"no source", &Starlark{}},
+		{"both source and script", &Starlark{Source: "def apply(m): return m", Script: "x.star"}},
+		{"syntax error", &Starlark{Source: "def apply(m) return m"}},
+		{"no apply", &Starlark{Source: "def transform(m): return m"}},
+		{"apply not a function", &Starlark{Source: "apply = 42"}},
+		{"apply parameters", &Starlark{Source: "def apply(a, b): return a"}},
+		{"load", &Starlark{Source: "load('module.star', 'x')\ndef apply(m): return m"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, tt.plugin.setup())
+
+			// metrics are passed through unmodified
+			m := newTestMetric(t)
+			assert.Equal(t, []telegraf.Metric{m}, tt.plugin.Apply(m))
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected func(m telegraf.Metric) []telegraf.Metric
+	}{
+		{
+			name: "modify",
+			source: `
+def apply(metric):
+	metric.name = "cpu2"
+	metric.tags["region"] = "eu"
+	metric.tags.pop("host")
+	metric.fields["time_idle"] = metric.fields["time_idle"] * 2
+	metric.fields["up"] = True
+	metric.fields.pop("count")
+	metric.time = metric.time + 1000000000
+	return metric
+`,
+			expected: func(_ telegraf.Metric) []telegraf.Metric {
+				m, _ := metric.New("cpu2",
+					map[string]string{"region": "eu"},
+					map[string]interface{}{"time_idle": 84.0, "up": true},
+					time.Unix(1, 0),
+				)
+				return []telegraf.Metric{m}
+			},
+		},
+		{
+			name: "drop",
+			source: `
+def apply(metric):
+	return None
+`,
+			expected: func(_ telegraf.Metric) []telegraf.Metric { return []telegraf.Metric{} },
+		},
+		{
+			name: "split",
+			source: `
+def apply(metric):
+	metrics = []
+	for k in sorted(metric.fields.keys()):
+		m = Metric(metric.name + "_" + k)
+		m.tags.update(metric.tags)
+		m.fields["value"] = metric.fields[k]
+		m.time = metric.time
+		metrics.append(m)
+	return metrics
+`,
+			expected: func(_ telegraf.Metric) []telegraf.Metric {
+				m1, _ := metric.New("cpu_count",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{"value": int64(1)},
+					time.Unix(0, 0),
+				)
+				m2, _ := metric.New("cpu_time_idle",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{"value": 42.0},
+					time.Unix(0, 0),
+				)
+				return []telegraf.Metric{m1, m2}
+			},
+		},
+		{
+			name: "emit a copy",
+			source: `
+def apply(metric):
+	copy = deepcopy(metric)
+	copy.name = "cpu_copy"
+	return [metric, copy]
+`,
+			expected: func(m telegraf.Metric) []telegraf.Metric {
+				c := m.Copy()
+				c.SetName("cpu_copy")
+				return []telegraf.Metric{m, c}
+			},
+		},
+		{
+			name: "runtime error",
+			source: `
+def apply(metric):
+	return metric.fields["missing"]
+`,
+			expected: func(m telegraf.Metric) []telegraf.Metric { return []telegraf.Metric{m} },
+		},
+		{
+			name: "invalid return value",
+			source: `
+def apply(metric):
+	return 42
+`,
+			expected: func(m telegraf.Metric) []telegraf.Metric { return []telegraf.Metric{m} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Starlark{Source: tt.source}
+			m := newTestMetric(t)
+			expected := tt.expected(m.Copy())
+
+			actual := plugin.Apply(m)
+			require.Len(t, actual, len(expected))
+			for i := range expected {
+				assert.Equal(t, expected[i].Name(), actual[i].Name())
+				assert.Equal(t, expected[i].Tags(), actual[i].Tags())
+				assert.Equal(t, expected[i].Fields(), actual[i].Fields())
+				assert.Equal(t, expected[i].Time().UnixNano(), actual[i].Time().UnixNano())
+			}
+		})
+	}
+}
+
+func TestStateIsKeptBetweenCalls(t *testing.T) {
+	plugin := &Starlark{Source: `
+def apply(metric):
+	last = state.get(metric.name)
+	state[metric.name] = metric.fields["count"]
+	if last != None:
+		metric.fields["delta"] = metric.fields["count"] - last
+	return metric
+`}
+
+	for i, count := range []int64{1, 5, 12} {
+		m := newTestMetric(t)
+		m.AddField("count", count)
+		out := plugin.Apply(m)
+		require.Len(t, out, 1)
+		delta, ok := out[0].GetField("delta")
+		if i == 0 {
+			assert.False(t, ok)
+			continue
+		}
+		assert.True(t, ok)
+		assert.Equal(t, count-[]int64{1, 5, 12}[i-1], delta)
+	}
+}
+
+func TestConcurrentApply(t *testing.T) {
+	plugin := &Starlark{Source: `
+def apply(metric):
+	state["calls"] = state.get("calls", 0) + 1
+	metric.fields["id"] = metric.fields["count"]
+	return metric
+`}
+
+	// the agent applies processors from two goroutines
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for j := int64(0); j < 1000; j++ {
+				m := newTestMetric(t)
+				m.AddField("count", j)
+				out := plugin.Apply(m)
+				require.Len(t, out, 1)
+				assert.True(t, out[0] == m)
+				id, _ := out[0].GetField("id")
+				assert.Equal(t, j, id)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+}
+
+func TestRate(t *testing.T) {
+	plugin := &Starlark{Source: `
+def apply(metric):
+	key = metric.name + str(sorted(metric.tags.items()))
+	last = state.get(key)
+	state[key] = (metric.time, metric.fields["count"])
+	if last != None:
+		elapsed = (metric.time - last[0]) / 1e9
+		if elapsed > 0:
+			metric.fields["rate"] = (metric.fields["count"] - last[1]) / elapsed
+	return metric
+`}
+
+	m1 := newTestMetric(t)
+	m2, err := metric.New("cpu", m1.Tags(),
+		map[string]interface{}{"count": int64(21)}, time.Unix(10, 0))
+	require.NoError(t, err)
+
+	out := plugin.Apply(m1, m2)
+	require.Len(t, out, 2)
+	assert.False(t, out[0].HasField("rate"))
+	rate, ok := out[1].GetField("rate")
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, rate)
+}
+
+func TestFrozenMetric(t *testing.T) {
+	plugin := &Starlark{Source: `
+frozen = Metric("frozen")
+
+def apply(metric):
+	frozen.name = "modified"
+	return metric
+`}
+	m := newTestMetric(t)
+	// the error is logged and the metric passed through
+	assert.Equal(t, []telegraf.Metric{m}, plugin.Apply(m))
+}
+
+func TestInvalidTagAndFieldValues(t *testing.T) {
+	for _, source := range []string{
+		"def apply(metric):\n\tmetric.tags['x'] = 1\n\treturn metric",
+		"def apply(metric):\n\tmetric.fields['x'] = [1]\n\treturn metric",
+		"def apply(metric):\n\tmetric.tags = {}\n\treturn metric",
+		"def apply(metric):\n\tmetric.time = 'now'\n\treturn metric",
+	} {
+		plugin := &Starlark{Source: source}
+		m := newTestMetric(t)
+		out := plugin.Apply(m)
+		require.Len(t, out, 1)
+		assert.Equal(t, newTestMetric(t).Fields(), out[0].Fields())
+		assert.Equal(t, newTestMetric(t).Tags(), out[0].Tags())
+	}
+}
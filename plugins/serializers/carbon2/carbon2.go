@@ -0,0 +1,102 @@
+package carbon2
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Carbon 2.0 separates the parts of a line, and the key and value of each
+// tag, with spaces and equal signs, so they are replaced in names and values.
+var sanitizer = strings.NewReplacer(
+	" ", "_",
+	"=", "_",
+	"\n", "_",
+)
+
+// Serializer writes metrics in the Carbon 2.0 format, one line per field:
+//
+//	metric=<name> field=<field> <tag>=<value>...  <value> <timestamp>
+//
+// The tags of the metric are written as intrinsic tags, followed by an empty
+// list of meta tags.
+type Serializer struct{}
+
+func NewSerializer() *Serializer {
+	return &Serializer{}
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+
+	// Carbon 2.0 timestamps are in seconds.
+	timestamp := strconv.FormatInt(metric.Time().Unix(), 10)
+
+	// Sort the fields so that the output does not depend on the order the
+	// fields were added in.
+	fields := append([]*telegraf.Field(nil), metric.FieldList()...)
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Key < fields[j].Key
+	})
+
+	for _, field := range fields {
+		value := formatValue(field.Value)
+		if value == "" {
+			continue
+		}
+
+		buf.WriteString("metric=")
+		buf.WriteString(sanitizer.Replace(metric.Name()))
+		buf.WriteString(" field=")
+		buf.WriteString(sanitizer.Replace(field.Key))
+		buf.WriteString(" ")
+		for _, tag := range metric.TagList() {
+			buf.WriteString(sanitizer.Replace(tag.Key))
+			buf.WriteString("=")
+			buf.WriteString(sanitizer.Replace(tag.Value))
+			buf.WriteString(" ")
+		}
+		buf.WriteString(" ")
+		buf.WriteString(value)
+		buf.WriteString(" ")
+		buf.WriteString(timestamp)
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var batch bytes.Buffer
+	for _, m := range metrics {
+		buf, err := s.Serialize(m)
+		if err != nil {
+			return nil, err
+		}
+		batch.Write(buf)
+	}
+	return batch.Bytes(), nil
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package carbon2
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestSerialize(t *testing.T) {
+	m, err := metric.New(
+		"cpu",
+		map[string]string{"cpu": "cpu0", "host": "localhost"},
+		map[string]interface{}{"usage_idle": 91.5},
+		time.Unix(1455320660, 0),
+	)
+	require.NoError(t, err)
+
+	s := NewSerializer()
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t,
+		"metric=cpu field=usage_idle cpu=cpu0 host=localhost  91.5 1455320660\n",
+		string(buf))
+}
+
+func TestSerializeNoTags(t *testing.T) {
+	m, err := metric.New(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{"usage_idle": 91.5},
+		time.Unix(1455320660, 0),
+	)
+	require.NoError(t, err)
+
+	s := NewSerializer()
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t,
+		"metric=cpu field=usage_idle  91.5 1455320660\n",
+		string(buf))
+}
+
+func TestSerializeFieldTypes(t *testing.T) {
+	m, err := metric.New(
+		"system",
+		map[string]string{},
+		map[string]interface{}{
+			"a": int64(-1),
+			"b": uint64(42),
+			"c": true,
+			"d": "up 2 days",
+			"e": math.Inf(1),
+		},
+		time.Unix(1455320660, 0),
+	)
+	require.NoError(t, err)
+
+	s := NewSerializer()
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t,
+		"metric=system field=a  -1 1455320660\n"+
+			"metric=system field=b  42 1455320660\n"+
+			"metric=system field=c  1 1455320660\n",
+		string(buf))
+}
+
+func TestSerializeSanitize(t *testing.T) {
+	m, err := metric.New(
+		"disk io",
+		map[string]string{"path": "/mnt/my disk", "opt=x": "y"},
+		map[string]interface{}{"read bytes": int64(1)},
+		time.Unix(1455320660, 0),
+	)
+	require.NoError(t, err)
+
+	s := NewSerializer()
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t,
+		"metric=disk_io field=read_bytes opt_x=y path=/mnt/my_disk  1 1455320660\n",
+		string(buf))
+}
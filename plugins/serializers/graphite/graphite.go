@@ -1,6 +1,7 @@
 package graphite
 
 import (
+	"bytes"
 	"fmt"
 	"math"
 	"regexp"
@@ -60,6 +61,18 @@ func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 	return out, nil
 }
 
+func (s *GraphiteSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var batch bytes.Buffer
+	for _, m := range metrics {
+		buf, err := s.Serialize(m)
+		if err != nil {
+			return nil, err
+		}
+		batch.Write(buf)
+	}
+	return batch.Bytes(), nil
+}
+
 func formatValue(value interface{}) string {
 	switch v := value.(type) {
 	case string:
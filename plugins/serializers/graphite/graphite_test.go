@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 )
 
@@ -59,6 +60,24 @@ func TestGraphiteTags(t *testing.T) {
 	assert.Equal(t, "first.second", tags3)
 }
 
+func TestSerializeBatch(t *testing.T) {
+	m, err := metric.New(
+		"cpu",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{
+			"value": 42.0,
+		},
+		time.Unix(0, 0),
+	)
+	require.NoError(t, err)
+
+	metrics := []telegraf.Metric{m, m}
+	s := GraphiteSerializer{}
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, []byte("localhost.cpu 42 0\nlocalhost.cpu 42 0\n"), buf)
+}
+
 func TestSerializeMetricNoHost(t *testing.T) {
 	now := time.Now()
 	tags := map[string]string{
@@ -102,6 +102,33 @@ func (s *Serializer) Serialize(m telegraf.Metric) ([]byte, error) {
 	return out, nil
 }
 
+// SerializeBatch writes the metrics to a byte slice, one after the other.
+// Metrics that cannot be serialized are discarded.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	s.buf.Reset()
+	for _, m := range metrics {
+		n := s.buf.Len()
+		err := s.writeMetric(&s.buf, m)
+		if err != nil {
+			// Remove any lines already written for the metric.
+			s.buf.Truncate(n)
+			switch err.(type) {
+			case *MetricError:
+				log.Printf(
+					"D! [serializers.influx] could not serialize metric %q: %v; discarding metric",
+					m.Name(), err)
+				continue
+			default:
+				return nil, err
+			}
+		}
+	}
+
+	out := make([]byte, s.buf.Len())
+	copy(out, s.buf.Bytes())
+	return out, nil
+}
+
 func (s *Serializer) Write(w io.Writer, m telegraf.Metric) (int, error) {
 	err := s.writeMetric(w, m)
 	return s.bytesWritten, err
@@ -433,6 +433,35 @@ func TestSerializer(t *testing.T) {
 	}
 }
 
+func TestSerializeBatch(t *testing.T) {
+	m := MustMetric(
+		metric.New(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"value": 42.0,
+			},
+			time.Unix(0, 0),
+		),
+	)
+	invalid := MustMetric(
+		metric.New(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"value": math.NaN(),
+			},
+			time.Unix(0, 0),
+		),
+	)
+
+	metrics := []telegraf.Metric{m, invalid, m}
+	serializer := NewSerializer()
+	output, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, []byte("cpu value=42 0\ncpu value=42 0\n"), output)
+}
+
 func BenchmarkSerializer(b *testing.B) {
 	for _, tt := range tests {
 		b.Run(tt.name, func(b *testing.B) {
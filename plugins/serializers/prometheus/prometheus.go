@@ -0,0 +1,362 @@
+package prometheus
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+var (
+	labelValueEscaper = strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+	)
+)
+
+// Serializer writes metrics in the Prometheus text exposition format, as read
+// by the node_exporter textfile collector and the Prometheus pushgateway.
+type Serializer struct {
+	// ExportTimestamp adds the metric time, in milliseconds, to each sample.
+	// The textfile collector refuses samples with a timestamp.
+	ExportTimestamp bool
+
+	// StringAsLabel adds string fields as labels, instead of dropping them.
+	StringAsLabel bool
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	name      string
+	series    string // identifies the labels of the metric
+	labels    []label
+	value     float64
+	timestamp int64
+}
+
+type bucket struct {
+	bound float64
+	value float64
+}
+
+type family struct {
+	name    string
+	typ     string
+	samples []sample
+	// index of the samples by sample name and labels
+	index map[string]int
+}
+
+func NewSerializer(exportTimestamp, stringAsLabel bool) *Serializer {
+	return &Serializer{
+		ExportTimestamp: exportTimestamp,
+		StringAsLabel:   stringAsLabel,
+	}
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.serialize([]telegraf.Metric{metric}), nil
+}
+
+// SerializeBatch writes the metrics of the batch grouped by metric family,
+// which avoids repeating the TYPE line of a family for each metric.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	return s.serialize(metrics), nil
+}
+
+// serialize groups the samples of the metrics by metric family, so that each
+// family is written once with a single TYPE line.
+func (s *Serializer) serialize(metrics []telegraf.Metric) []byte {
+	families := make(map[string]*family)
+	for _, m := range metrics {
+		s.addMetric(families, m)
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		f := families[name]
+		buf.WriteString("# TYPE ")
+		buf.WriteString(f.name)
+		buf.WriteString(" ")
+		buf.WriteString(f.typ)
+		buf.WriteString("\n")
+		// Group the samples by series; the sort is stable so that the
+		// samples of a metric, such as histogram buckets, keep their order.
+		sort.SliceStable(f.samples, func(i, j int) bool {
+			return f.samples[i].series < f.samples[j].series
+		})
+		for _, smp := range f.samples {
+			s.writeSample(&buf, smp)
+		}
+	}
+	return buf.Bytes()
+}
+
+func (s *Serializer) addMetric(families map[string]*family, metric telegraf.Metric) {
+	labels := s.labels(metric)
+	series := seriesKey(labels)
+	timestamp := metric.Time().UnixNano() / 1000000
+	name := SanitizeMetricName(metric.Name())
+
+	add := func(familyName, typ string, smp sample) {
+		smp.series = series
+		smp.timestamp = timestamp
+		f, ok := families[familyName]
+		if !ok {
+			f = &family{
+				name:  familyName,
+				typ:   typ,
+				index: make(map[string]int),
+			}
+			families[familyName] = f
+		} else if f.typ != typ {
+			// Samples of different types can't share a family.
+			f.typ = "untyped"
+		}
+
+		// A series has a single sample, the latest one of the batch.
+		key := smp.name + "\x00" + seriesKey(smp.labels)
+		if i, ok := f.index[key]; ok {
+			if smp.timestamp >= f.samples[i].timestamp {
+				f.samples[i] = smp
+			}
+			return
+		}
+		f.index[key] = len(f.samples)
+		f.samples = append(f.samples, smp)
+	}
+
+	switch metric.Type() {
+	case telegraf.Summary, telegraf.Histogram:
+		typ := "summary"
+		bucketName, bucketLabel := name, "quantile"
+		if metric.Type() == telegraf.Histogram {
+			typ = "histogram"
+			bucketName, bucketLabel = name+"_bucket", "le"
+		}
+
+		var sum, count float64
+		var hasSum, hasCount bool
+		var buckets []bucket
+		for _, field := range metric.FieldList() {
+			value, ok := toFloat(field.Value)
+			if !ok {
+				continue
+			}
+			switch field.Key {
+			case "sum":
+				sum, hasSum = value, true
+				continue
+			case "count":
+				count, hasCount = value, true
+				continue
+			}
+			bound, err := strconv.ParseFloat(field.Key, 64)
+			if err != nil {
+				continue
+			}
+			buckets = append(buckets, bucket{bound: bound, value: value})
+		}
+		if !hasSum || !hasCount {
+			return
+		}
+		sort.Slice(buckets, func(i, j int) bool {
+			return buckets[i].bound < buckets[j].bound
+		})
+		// A histogram always ends with the +Inf bucket, holding the count.
+		if typ == "histogram" &&
+			(len(buckets) == 0 || !math.IsInf(buckets[len(buckets)-1].bound, 1)) {
+			buckets = append(buckets, bucket{bound: math.Inf(1), value: count})
+		}
+		for _, b := range buckets {
+			add(name, typ, sample{
+				name:   bucketName,
+				labels: withLabel(labels, bucketLabel, formatFloat(b.bound)),
+				value:  b.value,
+			})
+		}
+		add(name, typ, sample{name: name + "_sum", labels: labels, value: sum})
+		add(name, typ, sample{name: name + "_count", labels: labels, value: count})
+	default:
+		typ := "untyped"
+		switch metric.Type() {
+		case telegraf.Counter:
+			typ = "counter"
+		case telegraf.Gauge:
+			typ = "gauge"
+		}
+
+		// Only one field is named after the measurement: the field named
+		// after the metric type if there is one, else the value field.
+		valueField := "value"
+		if (typ == "counter" || typ == "gauge") && metric.HasField(typ) {
+			valueField = typ
+		}
+
+		fields := append([]*telegraf.Field(nil), metric.FieldList()...)
+		sort.Slice(fields, func(i, j int) bool {
+			return fields[i].Key < fields[j].Key
+		})
+		for _, field := range fields {
+			value, ok := toFloat(field.Value)
+			if !ok {
+				continue
+			}
+			var sampleName string
+			switch field.Key {
+			case valueField:
+				sampleName = name
+			default:
+				sampleName = SanitizeMetricName(metric.Name() + "_" + field.Key)
+			}
+			add(sampleName, typ, sample{name: sampleName, labels: labels, value: value})
+		}
+	}
+}
+
+// labels returns the sorted labels of a metric, made of its tags and, if
+// enabled, its string fields.
+func (s *Serializer) labels(metric telegraf.Metric) []label {
+	labels := make([]label, 0, len(metric.TagList()))
+	seen := make(map[string]bool)
+	for _, tag := range metric.TagList() {
+		name := SanitizeLabelName(tag.Key)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		labels = append(labels, label{name: name, value: tag.Value})
+	}
+
+	if s.StringAsLabel {
+		for _, field := range metric.FieldList() {
+			str, ok := field.Value.(string)
+			if !ok {
+				continue
+			}
+			name := SanitizeLabelName(field.Key)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			labels = append(labels, label{name: name, value: str})
+		}
+	}
+
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].name < labels[j].name
+	})
+	return labels
+}
+
+func (s *Serializer) writeSample(buf *bytes.Buffer, smp sample) {
+	buf.WriteString(smp.name)
+	if len(smp.labels) > 0 {
+		buf.WriteString("{")
+		for i, l := range smp.labels {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString(l.name)
+			buf.WriteString(`="`)
+			buf.WriteString(labelValueEscaper.Replace(l.value))
+			buf.WriteString(`"`)
+		}
+		buf.WriteString("}")
+	}
+	buf.WriteString(" ")
+	buf.WriteString(formatFloat(smp.value))
+	if s.ExportTimestamp {
+		buf.WriteString(" ")
+		buf.WriteString(strconv.FormatInt(smp.timestamp, 10))
+	}
+	buf.WriteString("\n")
+}
+
+// seriesKey returns a string identifying the series of a set of labels.
+func seriesKey(labels []label) string {
+	var buf bytes.Buffer
+	for _, l := range labels {
+		buf.WriteString(l.name)
+		buf.WriteByte(0)
+		buf.WriteString(l.value)
+		buf.WriteByte(0)
+	}
+	return buf.String()
+}
+
+// withLabel returns a copy of the labels with an additional label, which is
+// placed last as the Prometheus client libraries do.
+func withLabel(labels []label, name, value string) []label {
+	l := make([]label, 0, len(labels)+1)
+	l = append(l, labels...)
+	return append(l, label{name: name, value: value})
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// SanitizeMetricName replaces the characters not allowed in a Prometheus
+// metric name with underscores.
+func SanitizeMetricName(name string) string {
+	return sanitize(name, true)
+}
+
+// SanitizeLabelName replaces the characters not allowed in a Prometheus
+// label name with underscores.
+func SanitizeLabelName(name string) string {
+	return sanitize(name, false)
+}
+
+func sanitize(name string, allowColon bool) string {
+	b := []byte(name)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		case c >= '0' && c <= '9':
+		case c == ':' && allowColon:
+		default:
+			b[i] = '_'
+		}
+	}
+	// Names can't start with a digit.
+	if len(b) > 0 && b[0] >= '0' && b[0] <= '9' {
+		return "_" + string(b)
+	}
+	return string(b)
+}
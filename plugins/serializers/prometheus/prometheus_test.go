@@ -0,0 +1,269 @@
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func mustMetric(
+	t *testing.T,
+	name string,
+	tags map[string]string,
+	fields map[string]interface{},
+	tm time.Time,
+	tp ...telegraf.ValueType,
+) telegraf.Metric {
+	m, err := metric.New(name, tags, fields, tm, tp...)
+	require.NoError(t, err)
+	return m
+}
+
+func TestSerializeGauge(t *testing.T) {
+	m := mustMetric(t, "cpu",
+		map[string]string{"host": "example.org", "cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": 42.5, "usage_user": int64(3)},
+		time.Unix(0, 0),
+		telegraf.Gauge,
+	)
+
+	s := NewSerializer(false, false)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `# TYPE cpu_usage_idle gauge
+cpu_usage_idle{cpu="cpu0",host="example.org"} 42.5
+# TYPE cpu_usage_user gauge
+cpu_usage_user{cpu="cpu0",host="example.org"} 3
+`, string(buf))
+}
+
+func TestSerializeValueField(t *testing.T) {
+	m := mustMetric(t, "http_requests",
+		map[string]string{},
+		map[string]interface{}{"counter": uint64(1027), "value": 7.0},
+		time.Unix(0, 0),
+		telegraf.Counter,
+	)
+
+	s := NewSerializer(false, false)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `# TYPE http_requests counter
+http_requests 1027
+# TYPE http_requests_value counter
+http_requests_value 7
+`, string(buf))
+}
+
+func TestSerializeUntyped(t *testing.T) {
+	m := mustMetric(t, "mem",
+		map[string]string{},
+		map[string]interface{}{"gauge": 1.0, "used": int64(2)},
+		time.Unix(0, 0),
+	)
+
+	s := NewSerializer(false, false)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `# TYPE mem_gauge untyped
+mem_gauge 1
+# TYPE mem_used untyped
+mem_used 2
+`, string(buf))
+}
+
+func TestSerializeHistogram(t *testing.T) {
+	m := mustMetric(t, "http_request_duration_seconds",
+		map[string]string{"code": "200"},
+		map[string]interface{}{
+			"sum":   53423.0,
+			"count": uint64(144320),
+			"1":     uint64(133988),
+			"0.5":   uint64(129389),
+			"0.05":  uint64(24054),
+		},
+		time.Unix(0, 0),
+		telegraf.Histogram,
+	)
+
+	s := NewSerializer(false, false)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{code="200",le="0.05"} 24054
+http_request_duration_seconds_bucket{code="200",le="0.5"} 129389
+http_request_duration_seconds_bucket{code="200",le="1"} 133988
+http_request_duration_seconds_bucket{code="200",le="+Inf"} 144320
+http_request_duration_seconds_sum{code="200"} 53423
+http_request_duration_seconds_count{code="200"} 144320
+`, string(buf))
+}
+
+func TestSerializeSummary(t *testing.T) {
+	m := mustMetric(t, "rpc_duration_seconds",
+		map[string]string{},
+		map[string]interface{}{
+			"sum":   1.7560473e+07,
+			"count": uint64(2693),
+			"0.99":  76656.0,
+			"0.5":   4773.0,
+		},
+		time.Unix(0, 0),
+		telegraf.Summary,
+	)
+
+	s := NewSerializer(false, false)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} 4773
+rpc_duration_seconds{quantile="0.99"} 76656
+rpc_duration_seconds_sum 1.7560473e+07
+rpc_duration_seconds_count 2693
+`, string(buf))
+}
+
+func TestSerializeSummaryWithoutCount(t *testing.T) {
+	m := mustMetric(t, "rpc_duration_seconds",
+		map[string]string{},
+		map[string]interface{}{"sum": 1.0, "0.5": 4773.0},
+		time.Unix(0, 0),
+		telegraf.Summary,
+	)
+
+	s := NewSerializer(false, false)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "", string(buf))
+}
+
+func TestSerializeSanitize(t *testing.T) {
+	m := mustMetric(t, "0disk.io",
+		map[string]string{"dev-name": "sda", "path": `C:\ "x"`},
+		map[string]interface{}{"reads/sec": 1.0},
+		time.Unix(0, 0),
+	)
+
+	s := NewSerializer(false, false)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `# TYPE _0disk_io_reads_sec untyped
+_0disk_io_reads_sec{dev_name="sda",path="C:\\ \"x\""} 1
+`, string(buf))
+}
+
+func TestSerializeStringFields(t *testing.T) {
+	m := mustMetric(t, "system",
+		map[string]string{},
+		map[string]interface{}{"uptime": int64(42), "uptime_format": "0:00", "ok": true},
+		time.Unix(0, 0),
+	)
+
+	s := NewSerializer(false, false)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `# TYPE system_uptime untyped
+system_uptime 42
+`, string(buf))
+
+	s = NewSerializer(false, true)
+	buf, err = s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `# TYPE system_uptime untyped
+system_uptime{uptime_format="0:00"} 42
+`, string(buf))
+}
+
+func TestSerializeTimestamp(t *testing.T) {
+	m := mustMetric(t, "cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(1500000000, 123456789),
+	)
+
+	s := NewSerializer(true, false)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `# TYPE cpu untyped
+cpu 42 1500000000123
+`, string(buf))
+}
+
+func TestSerializeBatchSortsSeries(t *testing.T) {
+	m1 := mustMetric(t, "http_request_duration_seconds",
+		map[string]string{"code": "500"},
+		map[string]interface{}{"0.1": int64(1), "sum": 0.2, "count": int64(2)},
+		time.Unix(0, 0),
+		telegraf.Histogram,
+	)
+	m2 := mustMetric(t, "http_request_duration_seconds",
+		map[string]string{"code": "200"},
+		map[string]interface{}{"0.1": int64(3), "sum": 0.5, "count": int64(4)},
+		time.Unix(0, 0),
+		telegraf.Histogram,
+	)
+
+	s := NewSerializer(false, false)
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2})
+	require.NoError(t, err)
+	require.Equal(t, `# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{code="200",le="0.1"} 3
+http_request_duration_seconds_bucket{code="200",le="+Inf"} 4
+http_request_duration_seconds_sum{code="200"} 0.5
+http_request_duration_seconds_count{code="200"} 4
+http_request_duration_seconds_bucket{code="500",le="0.1"} 1
+http_request_duration_seconds_bucket{code="500",le="+Inf"} 2
+http_request_duration_seconds_sum{code="500"} 0.2
+http_request_duration_seconds_count{code="500"} 2
+`, string(buf))
+}
+
+func TestSerializeBatch(t *testing.T) {
+	m1 := mustMetric(t, "cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": 42.0},
+		time.Unix(0, 0),
+	)
+	m2 := mustMetric(t, "cpu",
+		map[string]string{"cpu": "cpu1"},
+		map[string]interface{}{"usage_idle": 43.0},
+		time.Unix(0, 0),
+	)
+
+	s := NewSerializer(false, false)
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2})
+	require.NoError(t, err)
+	require.Equal(t, `# TYPE cpu_usage_idle untyped
+cpu_usage_idle{cpu="cpu0"} 42
+cpu_usage_idle{cpu="cpu1"} 43
+`, string(buf))
+}
+
+func TestSerializeBatchKeepsLatestSample(t *testing.T) {
+	m1 := mustMetric(t, "cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": 42.0},
+		time.Unix(10, 0),
+	)
+	m2 := mustMetric(t, "cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": 43.0},
+		time.Unix(20, 0),
+	)
+	m3 := mustMetric(t, "cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": 44.0},
+		time.Unix(0, 0),
+	)
+
+	s := NewSerializer(true, false)
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2, m3})
+	require.NoError(t, err)
+	require.Equal(t, `# TYPE cpu_usage_idle untyped
+cpu_usage_idle{cpu="cpu0"} 43 20000
+`, string(buf))
+}
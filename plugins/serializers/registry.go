@@ -6,9 +6,12 @@ import (
 
 	"github.com/influxdata/telegraf"
 
+	"github.com/influxdata/telegraf/plugins/serializers/carbon2"
 	"github.com/influxdata/telegraf/plugins/serializers/graphite"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/plugins/serializers/json"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	"github.com/influxdata/telegraf/plugins/serializers/splunkmetric"
 )
 
 // SerializerOutput is an interface for output plugins that are able to
@@ -25,12 +28,18 @@ type Serializer interface {
 	// separate metrics should be separated by a newline, and there should be
 	// a newline at the end of the buffer.
 	Serialize(metric telegraf.Metric) ([]byte, error)
+
+	// SerializeBatch takes an array of telegraf metric and serializes it into
+	// a byte buffer.  This method is not required to be suitable for use with
+	// line oriented framing.
+	SerializeBatch(metrics []telegraf.Metric) ([]byte, error)
 }
 
 // Config is a struct that covers the data types needed for all serializer types,
 // and can be used to instantiate _any_ of the serializers.
 type Config struct {
-	// Dataformat can be one of: influx, graphite, or json
+	// Dataformat can be one of: influx, graphite, json, prometheus, carbon2
+	// or splunkmetric
 	DataFormat string
 
 	// Maximum line length in bytes; influx format only
@@ -52,6 +61,16 @@ type Config struct {
 
 	// Timestamp units to use for JSON formatted output
 	TimestampUnits time.Duration
+
+	// Include the metric timestamp on each sample; prometheus format only
+	PrometheusExportTimestamp bool
+
+	// Add string fields as labels; prometheus format only
+	PrometheusStringAsLabel bool
+
+	// Wrap the metrics in events for the HTTP Event Collector; splunkmetric
+	// format only
+	SplunkmetricHecRouting bool
 }
 
 // NewSerializer a Serializer interface based on the given config.
@@ -65,6 +84,12 @@ func NewSerializer(config *Config) (Serializer, error) {
 		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template)
 	case "json":
 		serializer, err = NewJsonSerializer(config.TimestampUnits)
+	case "prometheus":
+		serializer, err = NewPrometheusSerializer(config)
+	case "carbon2":
+		serializer, err = NewCarbon2Serializer()
+	case "splunkmetric":
+		serializer, err = NewSplunkmetricSerializer(config.SplunkmetricHecRouting)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -103,3 +128,18 @@ func NewGraphiteSerializer(prefix, template string) (Serializer, error) {
 		Template: template,
 	}, nil
 }
+
+func NewPrometheusSerializer(config *Config) (Serializer, error) {
+	return prometheus.NewSerializer(
+		config.PrometheusExportTimestamp,
+		config.PrometheusStringAsLabel,
+	), nil
+}
+
+func NewCarbon2Serializer() (Serializer, error) {
+	return carbon2.NewSerializer(), nil
+}
+
+func NewSplunkmetricSerializer(hecRouting bool) (Serializer, error) {
+	return splunkmetric.NewSerializer(hecRouting), nil
+}
@@ -0,0 +1,114 @@
+package splunkmetric
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Serializer writes metrics as Splunk metric events, one JSON object per
+// field.  Without HEC routing, the objects are the "fields" of the events,
+// for use with a Splunk metrics index reading a file or a socket.  With HEC
+// routing, they are complete events that can be posted to the HTTP Event
+// Collector.
+type Serializer struct {
+	HecRouting bool
+}
+
+func NewSerializer(hecRouting bool) *Serializer {
+	return &Serializer{HecRouting: hecRouting}
+}
+
+type hecEvent struct {
+	Time   float64                `json:"time"`
+	Event  string                 `json:"event"`
+	Host   string                 `json:"host,omitempty"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+
+	// Splunk timestamps are in seconds, with a fractional part.
+	timestamp := float64(metric.Time().UnixNano()) / 1e9
+
+	// One event is written per field, ordered by field key.
+	fields := append([]*telegraf.Field(nil), metric.FieldList()...)
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Key < fields[j].Key
+	})
+
+	for _, field := range fields {
+		value, ok := toFloat(field.Value)
+		if !ok {
+			continue
+		}
+
+		fields := make(map[string]interface{}, len(metric.TagList())+3)
+		for _, tag := range metric.TagList() {
+			fields[tag.Key] = tag.Value
+		}
+		fields["metric_name"] = metric.Name() + "." + field.Key
+		fields["_value"] = value
+
+		var event interface{}
+		if s.HecRouting {
+			// The host is an indexed field of the event, not a dimension.
+			host := metric.Tags()["host"]
+			delete(fields, "host")
+			event = &hecEvent{
+				Time:   timestamp,
+				Event:  "metric",
+				Host:   host,
+				Fields: fields,
+			}
+		} else {
+			fields["time"] = timestamp
+			event = fields
+		}
+
+		b, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var batch bytes.Buffer
+	for _, m := range metrics {
+		buf, err := s.Serialize(m)
+		if err != nil {
+			return nil, err
+		}
+		batch.Write(buf)
+	}
+	return batch.Bytes(), nil
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		// NaN and infinity can't be encoded in JSON.
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0, false
+		}
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
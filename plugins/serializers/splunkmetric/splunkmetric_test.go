@@ -0,0 +1,66 @@
+package splunkmetric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestSerialize(t *testing.T) {
+	m, err := metric.New(
+		"cpu",
+		map[string]string{"cpu": "cpu0", "host": "localhost"},
+		map[string]interface{}{"usage_idle": 91.5},
+		time.Unix(1529708430, 500000000),
+	)
+	require.NoError(t, err)
+
+	s := NewSerializer(false)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t,
+		`{"_value":91.5,"cpu":"cpu0","host":"localhost","metric_name":"cpu.usage_idle","time":1529708430.5}`+"\n",
+		string(buf))
+}
+
+func TestSerializeHecRouting(t *testing.T) {
+	m, err := metric.New(
+		"cpu",
+		map[string]string{"cpu": "cpu0", "host": "localhost"},
+		map[string]interface{}{"usage_idle": 91.5},
+		time.Unix(1529708430, 0),
+	)
+	require.NoError(t, err)
+
+	s := NewSerializer(true)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t,
+		`{"time":1529708430,"event":"metric","host":"localhost","fields":{"_value":91.5,"cpu":"cpu0","metric_name":"cpu.usage_idle"}}`+"\n",
+		string(buf))
+}
+
+func TestSerializeFieldTypes(t *testing.T) {
+	m, err := metric.New(
+		"system",
+		map[string]string{},
+		map[string]interface{}{
+			"a": int64(-1),
+			"b": true,
+			"c": "up 2 days",
+		},
+		time.Unix(1529708430, 0),
+	)
+	require.NoError(t, err)
+
+	s := NewSerializer(false)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t,
+		`{"_value":-1,"metric_name":"system.a","time":1529708430}`+"\n"+
+			`{"_value":1,"metric_name":"system.b","time":1529708430}`+"\n",
+		string(buf))
+}